@@ -0,0 +1,255 @@
+package agent
+
+import (
+	"bytes"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+)
+
+const (
+	// DefaultDialTimeout is the timeout to dial an address
+	DefaultDialTimeout = 10 * time.Second
+
+	// MinDialBackoff is the initial delay to redial an address
+	MinDialBackoff = time.Second
+
+	// MaxDialBackoff is the maximum delay to redial an address, the delay
+	// doubles on each failure until MaxDialBackoff.
+	MaxDialBackoff = time.Minute
+)
+
+// PeerAddress is an entry of the address book, Identity is unknown(zero)
+// until a peer at Address has authenticated.
+type PeerAddress struct {
+	Identity bdls.Identity
+	Address  string
+}
+
+// addressEntry is an address being dialed by the agent
+type addressEntry struct {
+	identity bdls.Identity
+	die      chan struct{} // stop dialing
+}
+
+// AddAddress adds an address to the address book, the agent keeps a
+// connection to the address, dialing with exponential backoff and
+// redialing when the connection closes.
+func (agent *TCPAgent) AddAddress(address string) {
+	agent.Lock()
+	agent.addAddress(address)
+	agent.Unlock()
+	agent.saveAddressBook()
+}
+
+// addAddress starts dialing the address, and returns the entry of the
+// address, or nil if the agent has closed. The agent lock must be held.
+func (agent *TCPAgent) addAddress(address string) *addressEntry {
+	select {
+	case <-agent.die:
+		return nil
+	default:
+	}
+
+	if entry, ok := agent.addresses[address]; ok {
+		return entry
+	}
+	entry := &addressEntry{die: make(chan struct{})}
+	agent.addresses[address] = entry
+	go agent.dialLoop(address, entry)
+	return entry
+}
+
+// RemoveAddress removes an address from the address book and stops dialing,
+// the established connection will not be closed.
+func (agent *TCPAgent) RemoveAddress(address string) {
+	agent.Lock()
+	agent.removeAddress(address)
+	agent.Unlock()
+	agent.saveAddressBook()
+}
+
+// removeAddress stops dialing the address, the agent lock must be held.
+func (agent *TCPAgent) removeAddress(address string) {
+	if entry, ok := agent.addresses[address]; ok {
+		close(entry.die)
+		delete(agent.addresses, address)
+	}
+}
+
+// Addresses returns all entries in the address book
+func (agent *TCPAgent) Addresses() []PeerAddress {
+	agent.Lock()
+	defer agent.Unlock()
+	var addresses []PeerAddress
+	for address, entry := range agent.addresses {
+		addresses = append(addresses, PeerAddress{Identity: entry.identity, Address: address})
+	}
+	return addresses
+}
+
+// Serve accepts connections from the listener and initiates authentication
+// to them, it returns when the listener is closed, or nil when the agent
+// is closed.
+func (agent *TCPAgent) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		p := NewTCPPeer(conn, agent)
+		if !agent.AddPeer(p) {
+			p.Close()
+			select {
+			case <-agent.die:
+				return nil
+			default:
+				continue
+			}
+		}
+		// prove my identity to this peer
+		p.InitiatePublicKeyAuthentication()
+	}
+}
+
+// dialLoop keeps a connection to the address until the entry is removed or
+// the agent is closed.
+func (agent *TCPAgent) dialLoop(address string, entry *addressEntry) {
+	backoff := MinDialBackoff
+	for first := true; ; first = false {
+		// wait before redialing
+		if !first {
+			select {
+			case <-time.After(backoff):
+			case <-entry.die:
+				return
+			case <-agent.die:
+				return
+			}
+		}
+
+		conn, err := net.DialTimeout("tcp", address, DefaultDialTimeout)
+		if err != nil {
+			backoff = nextBackoff(backoff)
+			continue
+		}
+
+		// the address may have been banned
+		p := newTCPPeer(conn, agent, address)
+		if !agent.AddPeer(p) {
+			p.Close()
+			backoff = nextBackoff(backoff)
+			continue
+		}
+		// prove my identity to this peer
+		p.InitiatePublicKeyAuthentication()
+
+		// wait for the connection to close, if it has been superseded by a
+		// duplicated connection to the same identity, wait for that one.
+		established := false
+		for p != nil {
+			select {
+			case <-p.die:
+			case <-entry.die:
+				return
+			case <-agent.die:
+				return
+			}
+			if _, seq := p.sessionInfo(); seq != 0 {
+				established = true
+			}
+			p = p.superseded()
+		}
+
+		// reset backoff only if the connection has been established, to
+		// prevent from redialing a peer rejecting us quickly.
+		if established {
+			backoff = MinDialBackoff
+		} else {
+			backoff = nextBackoff(backoff)
+		}
+	}
+}
+
+// nextBackoff doubles the backoff until MaxDialBackoff
+func nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > MaxDialBackoff {
+		backoff = MaxDialBackoff
+	}
+	return backoff
+}
+
+// nextSessionSeq returns a sequence number for newly established session
+func (agent *TCPAgent) nextSessionSeq() uint64 {
+	return atomic.AddUint64(&agent.sessionSeq, 1)
+}
+
+// sessionEstablished records the identity of the dialed address, closes
+// connections to myself, and closes duplicated connections to the same
+// identity, which happens when two nodes dial each other at the same time.
+// Known peer records will be sent over the connection kept.
+//
+// Both nodes will keep the same connection: for connections in different
+// directions, the one dialed by the smaller identity is kept, otherwise the
+// newer one is kept.
+func (agent *TCPAgent) sessionEstablished(p *TCPPeer) {
+	pubkey, seq := p.sessionInfo()
+	if seq == 0 {
+		return
+	}
+	identity := bdls.DefaultPubKeyToIdentity(pubkey)
+	local := bdls.DefaultPubKeyToIdentity(&agent.privateKey.PublicKey)
+	localDialer := bytes.Compare(local[:], identity[:]) < 0
+
+	// connected to myself, stop dialing the address
+	if identity == local {
+		if p.dialAddress != "" {
+			agent.RemoveAddress(p.dialAddress)
+		}
+		p.Close()
+		return
+	}
+
+	agent.Lock()
+	if p.dialAddress != "" {
+		if entry, ok := agent.addresses[p.dialAddress]; ok {
+			entry.identity = identity
+		}
+	}
+	peers := make([]*TCPPeer, len(agent.peers))
+	copy(peers, agent.peers)
+	agent.Unlock()
+
+	for _, q := range peers {
+		if q == p {
+			continue
+		}
+		qkey, qseq := q.sessionInfo()
+		if qseq == 0 || bdls.DefaultPubKeyToIdentity(qkey) != identity {
+			continue
+		}
+
+		keep, drop := p, q
+		if p.outbound() != q.outbound() {
+			if p.outbound() != localDialer {
+				keep, drop = q, p
+			}
+		} else if qseq > seq {
+			keep, drop = q, p
+		}
+
+		drop.supersede(keep)
+		if drop == p {
+			return
+		}
+	}
+
+	// exchange known peer records
+	agent.Lock()
+	agent.sendPeerRecords(p)
+	agent.Unlock()
+}
@@ -0,0 +1,137 @@
+package agent
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+	"github.com/stretchr/testify/assert"
+)
+
+// establishedPeers returns peers with established session
+func establishedPeers(agent *TCPAgent) []*TCPPeer {
+	agent.Lock()
+	peers := make([]*TCPPeer, len(agent.peers))
+	copy(peers, agent.peers)
+	agent.Unlock()
+
+	var established []*TCPPeer
+	for _, p := range peers {
+		if _, seq := p.sessionInfo(); seq != 0 {
+			established = append(established, p)
+		}
+	}
+	return established
+}
+
+// waitEstablished waits until the agent has n established peers
+func waitEstablished(t *testing.T, agent *TCPAgent, n int) []*TCPPeer {
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		peers := establishedPeers(agent)
+		if len(peers) == n {
+			return peers
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%v peers established, expected %v", len(peers), n)
+		}
+		<-time.After(10 * time.Millisecond)
+	}
+}
+
+// serve starts serving the agent on a local port
+func serve(t *testing.T, agent *TCPAgent) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go agent.Serve(l)
+	return l
+}
+
+func TestAddressBookRedial(t *testing.T) {
+	agents := createAgents(t, 2)
+	for _, agent := range agents {
+		defer agent.Close()
+	}
+	l := serve(t, agents[0])
+	defer l.Close()
+
+	agents[1].AddAddress(l.Addr().String())
+	p := waitEstablished(t, agents[1], 1)[0]
+	waitEstablished(t, agents[0], 1)
+
+	// the identity has been learned
+	addresses := agents[1].Addresses()
+	assert.Equal(t, 1, len(addresses))
+	assert.Equal(t, l.Addr().String(), addresses[0].Address)
+	assert.Equal(t, bdls.DefaultPubKeyToIdentity(&agents[0].privateKey.PublicKey), addresses[0].Identity)
+
+	// redial on close
+	p.Close()
+	deadline := time.Now().Add(10 * time.Second)
+	q := p
+	for q == p {
+		if time.Now().After(deadline) {
+			t.Fatal("the address has not been redialed")
+		}
+		<-time.After(10 * time.Millisecond)
+		if peers := establishedPeers(agents[1]); len(peers) == 1 {
+			q = peers[0]
+		}
+	}
+
+	// stop dialing
+	agents[1].RemoveAddress(l.Addr().String())
+	assert.Equal(t, 0, len(agents[1].Addresses()))
+	q.Close()
+	<-time.After(2 * MinDialBackoff)
+	assert.Equal(t, 0, len(establishedPeers(agents[1])))
+}
+
+func TestDialDedupe(t *testing.T) {
+	agents := createAgents(t, 2)
+	for _, agent := range agents {
+		defer agent.Close()
+	}
+	l0 := serve(t, agents[0])
+	defer l0.Close()
+	l1 := serve(t, agents[1])
+	defer l1.Close()
+
+	// dial each other at the same time
+	agents[0].AddAddress(l1.Addr().String())
+	agents[1].AddAddress(l0.Addr().String())
+
+	// both keep the same single connection
+	<-time.After(time.Second)
+	p0 := waitEstablished(t, agents[0], 1)[0]
+	p1 := waitEstablished(t, agents[1], 1)[0]
+	assert.NotEqual(t, p0.outbound(), p1.outbound())
+	assert.Equal(t, p0.conn.LocalAddr().String(), p1.conn.RemoteAddr().String())
+
+	// and it's stable
+	<-time.After(2 * MinDialBackoff)
+	peers := establishedPeers(agents[0])
+	assert.True(t, len(peers) == 1 && peers[0] == p0)
+	peers = establishedPeers(agents[1])
+	assert.True(t, len(peers) == 1 && peers[0] == p1)
+}
+
+func TestDialMyself(t *testing.T) {
+	agents := createAgents(t, 1)
+	defer agents[0].Close()
+	l := serve(t, agents[0])
+	defer l.Close()
+
+	// the address of myself will be removed
+	agents[0].AddAddress(l.Addr().String())
+	deadline := time.Now().Add(10 * time.Second)
+	for len(agents[0].Addresses()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the address of myself has not been removed")
+		}
+		<-time.After(10 * time.Millisecond)
+	}
+	<-time.After(100 * time.Millisecond)
+	assert.Equal(t, 0, len(establishedPeers(agents[0])))
+}
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+
+	proto "github.com/gogo/protobuf/proto"
+)
+
+const (
+	// FeatureDeflate is announced in KeyAuthInit if the peer accepts DEFLATE
+	// compressed frames
+	FeatureDeflate uint64 = 1 << 0
+
+	// DefaultCompressionThreshold is the default minimum size of a frame to
+	// be compressed, used by EnableCompression
+	DefaultCompressionThreshold = 1024
+)
+
+// compressFrame compresses a gossip frame as a COMPRESSED frame, the frame
+// will be returned as is if it cannot be compressed smaller.
+func compressFrame(frame []byte) []byte {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		panic(err)
+	}
+	_, _ = w.Write(frame)
+	_ = w.Close()
+
+	out, err := proto.Marshal(&Gossip{Command: CommandType_COMPRESSED, Message: buf.Bytes()})
+	if err != nil {
+		panic(err)
+	}
+
+	if len(out) >= len(frame) {
+		return frame
+	}
+	return out
+}
+
+// decompressFrame decompresses the message of a COMPRESSED frame, the
+// decompressed size is limited to MaxMessageLength.
+func decompressFrame(compressed []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(compressed))
+	defer r.Close()
+
+	frame, err := io.ReadAll(io.LimitReader(r, MaxMessageLength+1))
+	if err != nil {
+		return nil, ErrFrameCompression
+	}
+
+	if len(frame) > MaxMessageLength {
+		return nil, ErrMessageLengthExceed
+	}
+	return frame, nil
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressFrame(t *testing.T) {
+	frame, err := proto.Marshal(&Gossip{Command: CommandType_PROPOSAL, Message: bytes.Repeat([]byte("state"), 1024)})
+	assert.Nil(t, err)
+
+	out := compressFrame(frame)
+	assert.True(t, len(out) < len(frame))
+	var g Gossip
+	assert.Nil(t, proto.Unmarshal(out, &g))
+	assert.Equal(t, CommandType_COMPRESSED, g.Command)
+	decompressed, err := decompressFrame(g.Message)
+	assert.Nil(t, err)
+	assert.Equal(t, frame, decompressed)
+
+	// random frames are sent as is
+	random := make([]byte, 1024)
+	_, err = io.ReadFull(rand.Reader, random)
+	assert.Nil(t, err)
+	assert.Equal(t, random, compressFrame(random))
+
+	_, err = decompressFrame(random)
+	assert.Equal(t, ErrFrameCompression, err)
+}
+
+func TestCompressionNegotiation(t *testing.T) {
+	testCompressionNegotiation(t, true)
+	testCompressionNegotiation(t, false)
+}
+
+func testCompressionNegotiation(t *testing.T, both bool) {
+	agents := createAgents(t, 2)
+	for _, agent := range agents {
+		defer agent.Close()
+	}
+	agents[0].SetCompression(16)
+	if both {
+		agents[1].EnableCompression()
+	}
+
+	p1, p2 := connectPipe(t, agents[0], agents[1])
+	waitEstablished(t, agents[0], 1)
+	waitEstablished(t, agents[1], 1)
+
+	p1.Lock()
+	assert.Equal(t, both, p1.localFeatures&p1.peerFeatures&FeatureDeflate != 0)
+	p1.Unlock()
+	if both {
+		p2.Lock()
+		assert.Equal(t, DefaultCompressionThreshold, p2.compressionThreshold)
+		p2.Unlock()
+	}
+
+	// proposals are delivered in both directions
+	hasProposed := func(agent *TCPAgent, s bdls.State) bool {
+		agent.Lock()
+		defer agent.Unlock()
+		return agent.consensus.HasProposed(s)
+	}
+
+	proposal := bdls.State(bytes.Repeat([]byte("proposal"), 1024))
+	agents[0].Propose(proposal)
+	agents[1].Propose(proposal[1:])
+	deadline := time.Now().Add(5 * time.Second)
+	for !hasProposed(agents[1], proposal) || !hasProposed(agents[0], proposal[1:]) {
+		if time.Now().After(deadline) {
+			t.Fatal("proposal has not been delivered in time")
+		}
+		<-time.After(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,317 @@
+package agent
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+	"github.com/BDLS-bft/bdls/crypto/blake2b"
+	proto "github.com/gogo/protobuf/proto"
+)
+
+const (
+	// PeerRecordPrefix is the prefix for signing a peer record
+	PeerRecordPrefix = "BDLS_PEER_RECORD"
+
+	// MaxPeerRecords is the maximum number of records in a PEER_RECORDS message
+	MaxPeerRecords = 256
+
+	// MaxKnownPeerRecords is the maximum number of identities with records,
+	// records of new identities beyond will be ignored, as each identity has
+	// one address from records, it caps the addresses learnt from peers.
+	MaxKnownPeerRecords = 1024
+
+	// MaxRecordClockSkew is the maximum duration a record's timestamp can be
+	// ahead of local clock, records beyond will be ignored.
+	MaxRecordClockSkew = time.Minute
+)
+
+// Hash concats and hash as follows:
+// blake2b(PeerRecordPrefix + X + Y + len_32bit(address) + address + timestamp)
+//
+// integers are encoded in little endian.
+func (r *PeerRecord) Hash() []byte {
+	hash, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	_, _ = hash.Write([]byte(PeerRecordPrefix))
+	_, _ = hash.Write(r.X)
+	_, _ = hash.Write(r.Y)
+	_ = binary.Write(hash, binary.LittleEndian, uint32(len(r.Address)))
+	_, _ = hash.Write([]byte(r.Address))
+	_ = binary.Write(hash, binary.LittleEndian, r.Timestamp)
+	return hash.Sum(nil)
+}
+
+// Sign the record with the peer's private key, the Address and Timestamp
+// fields should be set before signing.
+func (r *PeerRecord) Sign(privateKey *ecdsa.PrivateKey) {
+	r.X = privateKey.PublicKey.X.Bytes()
+	r.Y = privateKey.PublicKey.Y.Bytes()
+
+	rr, s, err := ecdsa.Sign(rand.Reader, privateKey, r.Hash())
+	if err != nil {
+		panic(err)
+	}
+	r.R = rr.Bytes()
+	r.S = s.Bytes()
+}
+
+// Verify the signature of this record
+func (r *PeerRecord) Verify() bool {
+	pubkey := r.PublicKey()
+	if !bdls.S256Curve.IsOnCurve(pubkey.X, pubkey.Y) {
+		return false
+	}
+
+	var R, S big.Int
+	R.SetBytes(r.R)
+	S.SetBytes(r.S)
+	return ecdsa.Verify(pubkey, r.Hash(), &R, &S)
+}
+
+// PublicKey returns the public key of the peer
+func (r *PeerRecord) PublicKey() *ecdsa.PublicKey {
+	pubkey := new(ecdsa.PublicKey)
+	pubkey.Curve = bdls.S256Curve
+	pubkey.X = big.NewInt(0).SetBytes(r.X)
+	pubkey.Y = big.NewInt(0).SetBytes(r.Y)
+	return pubkey
+}
+
+// addressBookFile is the persisted address book
+type addressBookFile struct {
+	Addresses []string `json:"addresses"`
+	Records   [][]byte `json:"records"` // protobuf encoded PeerRecord
+}
+
+// SetAdvertiseAddress signs a record of my identity with the address, and
+// announces it to peers, so they can dial me at the address.
+func (agent *TCPAgent) SetAdvertiseAddress(address string) {
+	r := new(PeerRecord)
+	r.Address = address
+	r.Timestamp = time.Now().UnixNano()
+	r.Sign(agent.privateKey)
+
+	agent.Lock()
+	agent.records[bdls.DefaultPubKeyToIdentity(&agent.privateKey.PublicKey)] = r
+	agent.gossipPeerRecords([]*PeerRecord{r}, nil)
+	agent.Unlock()
+	agent.saveAddressBook()
+}
+
+// OpenAddressBook loads the address book from the file if it exists, and
+// dials the addresses in it, later changes to the address book will be
+// saved to the file.
+func (agent *TCPAgent) OpenAddressBook(path string) error {
+	bts, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var book addressBookFile
+	if len(bts) > 0 {
+		if err := json.Unmarshal(bts, &book); err != nil {
+			return err
+		}
+	}
+
+	agent.Lock()
+	agent.addressBookPath = path
+	for _, address := range book.Addresses {
+		agent.addAddress(address)
+	}
+
+	now := time.Now()
+	for _, bts := range book.Records {
+		r := new(PeerRecord)
+		if err := proto.Unmarshal(bts, r); err != nil {
+			continue
+		}
+		agent.importPeerRecord(r, now)
+	}
+	agent.Unlock()
+	return nil
+}
+
+// saveAddressBook writes the address book to the file opened, the file is
+// replaced atomically.
+func (agent *TCPAgent) saveAddressBook() {
+	agent.Lock()
+	path := agent.addressBookPath
+	var book addressBookFile
+	for address := range agent.addresses {
+		book.Addresses = append(book.Addresses, address)
+	}
+	for _, r := range agent.records {
+		bts, err := proto.Marshal(r)
+		if err != nil {
+			panic(err)
+		}
+		book.Records = append(book.Records, bts)
+	}
+	agent.Unlock()
+
+	if path == "" {
+		return
+	}
+
+	bts, err := json.MarshalIndent(&book, "", "\t")
+	if err != nil {
+		panic(err)
+	}
+
+	agent.addressBookLock.Lock()
+	defer agent.addressBookLock.Unlock()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, bts, 0600); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, path)
+}
+
+// importPeerRecord verifies and stores a record, the address of the record
+// will be dialed, replacing the previous address of the identity. Returns
+// false if the record is not newer than what we have, or not allowed.
+// Without an identity policy, only records of participants are allowed, as
+// anyone could flood the address book with self-signed records otherwise.
+// The agent lock must be held.
+func (agent *TCPAgent) importPeerRecord(r *PeerRecord, now time.Time) bool {
+	if !r.Verify() {
+		return false
+	}
+
+	// records from the future
+	if time.Unix(0, r.Timestamp).After(now.Add(MaxRecordClockSkew)) {
+		return false
+	}
+
+	pubkey := r.PublicKey()
+	identity := bdls.DefaultPubKeyToIdentity(pubkey)
+	if identity == bdls.DefaultPubKeyToIdentity(&agent.privateKey.PublicKey) {
+		return false
+	}
+
+	agent.policyLock.Lock()
+	policy := agent.identityPolicy
+	agent.policyLock.Unlock()
+	if policy == nil {
+		policy = agent.consensus.IsParticipant
+	}
+	if !policy(pubkey) {
+		return false
+	}
+
+	known, ok := agent.records[identity]
+	if ok && known.Timestamp >= r.Timestamp {
+		return false
+	}
+
+	if !ok && len(agent.records) >= MaxKnownPeerRecords {
+		return false
+	}
+
+	// replace the previous address of the identity
+	for address, entry := range agent.addresses {
+		if entry.identity == identity && address != r.Address {
+			agent.removeAddress(address)
+		}
+	}
+	entry := agent.addAddress(r.Address)
+	if entry == nil {
+		return false
+	}
+	entry.identity = identity
+	agent.records[identity] = r
+	return true
+}
+
+// handlePeerRecords imports records announced by an authenticated peer,
+// and relays new records to other peers.
+func (agent *TCPAgent) handlePeerRecords(bts []byte, from *TCPPeer) error {
+	var m PeerRecords
+	if err := proto.Unmarshal(bts, &m); err != nil {
+		return err
+	}
+
+	if len(m.Records) > MaxPeerRecords {
+		return ErrPeerRecordsExceed
+	}
+
+	// relayed records have been verified, an invalid record is the sender's fault
+	for _, r := range m.Records {
+		if !r.Verify() {
+			return ErrPeerRecordSignature
+		}
+	}
+
+	now := time.Now()
+	var imported []*PeerRecord
+	agent.Lock()
+	for _, r := range m.Records {
+		if agent.importPeerRecord(r, now) {
+			imported = append(imported, r)
+		}
+	}
+	agent.gossipPeerRecords(imported, from)
+	agent.Unlock()
+
+	if len(imported) > 0 {
+		agent.saveAddressBook()
+	}
+	return nil
+}
+
+// sendPeerRecords sends all known records to the peer, the agent lock must
+// be held.
+func (agent *TCPAgent) sendPeerRecords(p *TCPPeer) {
+	var records []*PeerRecord
+	for _, r := range agent.records {
+		records = append(records, r)
+	}
+
+	for len(records) > 0 {
+		n := len(records)
+		if n > MaxPeerRecords {
+			n = MaxPeerRecords
+		}
+		p.sendAgentMessage(encodePeerRecords(records[:n]))
+		records = records[n:]
+	}
+}
+
+// gossipPeerRecords relays records to authenticated peers except the sender,
+// the agent lock must be held.
+func (agent *TCPAgent) gossipPeerRecords(records []*PeerRecord, from *TCPPeer) {
+	if len(records) == 0 {
+		return
+	}
+
+	out := encodePeerRecords(records)
+	for _, p := range agent.peers {
+		if p != from && p.GetPublicKey() != nil {
+			p.sendAgentMessage(out)
+		}
+	}
+}
+
+// encodePeerRecords encodes records as a PEER_RECORDS gossip message
+func encodePeerRecords(records []*PeerRecord) []byte {
+	bts, err := proto.Marshal(&PeerRecords{Records: records})
+	if err != nil {
+		panic(err)
+	}
+
+	out, err := proto.Marshal(&Gossip{Command: CommandType_PEER_RECORDS, Message: bts})
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
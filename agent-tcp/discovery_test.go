@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerRecordSign(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+
+	r := new(PeerRecord)
+	r.Address = "127.0.0.1:4680"
+	r.Timestamp = time.Now().UnixNano()
+	r.Sign(privateKey)
+	assert.True(t, r.Verify())
+	assert.Equal(t, privateKey.PublicKey.X, r.PublicKey().X)
+
+	r.Address = "127.0.0.1:4681"
+	assert.False(t, r.Verify())
+	r.Address = "127.0.0.1:4680"
+	r.Timestamp++
+	assert.False(t, r.Verify())
+}
+
+func TestDiscovery(t *testing.T) {
+	agents := createAgents(t, 3)
+	for _, agent := range agents {
+		defer agent.Close()
+	}
+
+	path := filepath.Join(t.TempDir(), "addrbook.json")
+	assert.Nil(t, agents[1].OpenAddressBook(path))
+
+	var addresses []string
+	for _, agent := range agents {
+		l := serve(t, agent)
+		defer l.Close()
+		addresses = append(addresses, l.Addr().String())
+		agent.SetAdvertiseAddress(l.Addr().String())
+	}
+
+	// only the seed is known
+	agents[1].AddAddress(addresses[0])
+	agents[2].AddAddress(addresses[0])
+
+	// agents 1 & 2 find each other
+	waitEstablished(t, agents[1], 2)
+	waitEstablished(t, agents[2], 2)
+	waitEstablished(t, agents[0], 2)
+
+	// the address book is persisted
+	agents[1].Close()
+	<-time.After(100 * time.Millisecond)
+	restarted := NewTCPAgent(agents[1].consensus, agents[1].privateKey)
+	defer restarted.Close()
+	assert.Nil(t, restarted.OpenAddressBook(path))
+	book := make(map[string]bdls.Identity)
+	for _, entry := range restarted.Addresses() {
+		book[entry.Address] = entry.Identity
+	}
+	assert.Equal(t, 2, len(book))
+	assert.Equal(t, bdls.DefaultPubKeyToIdentity(&agents[0].privateKey.PublicKey), book[addresses[0]])
+	assert.Equal(t, bdls.DefaultPubKeyToIdentity(&agents[2].privateKey.PublicKey), book[addresses[2]])
+}
+
+func TestPeerRecordLimits(t *testing.T) {
+	agents := createAgents(t, 2)
+	defer agents[0].Close()
+	agent := agents[0]
+
+	record := func(privateKey *ecdsa.PrivateKey, address string) *PeerRecord {
+		r := new(PeerRecord)
+		r.Address = address
+		r.Timestamp = time.Now().UnixNano()
+		r.Sign(privateKey)
+		return r
+	}
+
+	outsider, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	now := time.Now()
+
+	// without an identity policy, only records of participants are imported
+	agent.Lock()
+	assert.False(t, agent.importPeerRecord(record(outsider, "127.0.0.1:1"), now))
+	assert.True(t, agent.importPeerRecord(record(agents[1].privateKey, "127.0.0.1:2"), now))
+	agent.Unlock()
+
+	agent.SetIdentityPolicy(func(*ecdsa.PublicKey) bool { return true })
+	agent.Lock()
+	assert.True(t, agent.importPeerRecord(record(outsider, "127.0.0.1:1"), now))
+
+	// records of new identities are ignored when too many are known
+	for i := len(agent.records); i < MaxKnownPeerRecords; i++ {
+		agent.records[bdls.Identity{byte(i), byte(i >> 8), 1}] = new(PeerRecord)
+	}
+	another, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	assert.False(t, agent.importPeerRecord(record(another, "127.0.0.1:3"), now))
+	assert.True(t, agent.importPeerRecord(record(outsider, "127.0.0.1:4"), now))
+	agent.Unlock()
+
+	// too many records in a message
+	var records []*PeerRecord
+	for i := 0; i <= MaxPeerRecords; i++ {
+		records = append(records, record(outsider, "127.0.0.1:1"))
+	}
+	bts, err := proto.Marshal(&PeerRecords{Records: records})
+	assert.Nil(t, err)
+	assert.Equal(t, ErrPeerRecordsExceed, agent.handlePeerRecords(bts, nil))
+
+	// records are not imported after close
+	agents[1].Close()
+	agents[1].Lock()
+	assert.False(t, agents[1].importPeerRecord(record(agent.privateKey, "127.0.0.1:5"), now))
+	agents[1].Unlock()
+}
@@ -1,3 +1,4 @@
 // Package agent-tcp implements a TCP based agent to participate in consensus
-// Challenge-Response scheme has been adopted to do interactive authentication
+// Challenge-Response scheme has been adopted to do interactive authentication,
+// and frames are sealed with AES-GCM session keys derived from it.
 package agent
@@ -12,4 +12,20 @@ var (
 	ErrPeerKeyAuthChallengeResponse = errors.New("incorrect state for peer KeyAuthChallengeResponse message")
 	ErrPeerAuthenticatedFailed      = errors.New("public key authentication failed for peer")
 	ErrMessageLengthExceed          = errors.New("message size exceeded maximum")
+	ErrProposalSize                 = errors.New("proposal size exceeded maximum")
+	ErrFrameAuthentication          = errors.New("sealed frame authentication failed")
+	ErrFrameNotSealed               = errors.New("received a plaintext frame which must be sealed")
+	ErrSessionNotEstablished        = errors.New("received a sealed frame before session established")
+	ErrPeerIdentityRejected         = errors.New("the peer identity has been rejected by identity policy")
+	ErrPeerRecordsExceed            = errors.New("the number of peer records exceeded maximum")
+	ErrPeerRecordSignature          = errors.New("the peer record has an invalid signature")
+	ErrQueueFull                    = errors.New("the outgoing queue is full")
+	ErrFrameCompression             = errors.New("invalid compressed frame")
+	ErrCompressionNotNegotiated     = errors.New("received a compressed frame without negotiation")
+	ErrMessageLengthZero            = errors.New("received a zero length message")
+	ErrUnknownCommand               = errors.New("received a frame of unknown command")
+	ErrPeerBanned                   = errors.New("the peer has been banned")
+	ErrGossipVersion                = errors.New("the peer speaks an incompatible gossip protocol version")
+	ErrProtocolVersion              = errors.New("the peer runs an incompatible consensus protocol version")
+	ErrChainID                      = errors.New("the peer is on a different chain")
 )
@@ -31,14 +31,34 @@ const (
 	CommandType_KEY_AUTH_CHALLENGE       CommandType = 2
 	CommandType_KEY_AUTH_CHALLENGE_REPLY CommandType = 3
 	CommandType_CONSENSUS                CommandType = 4
+	CommandType_CLIENT_REQUEST           CommandType = 5
+	CommandType_PROPOSAL                 CommandType = 6
+	// SEALED carries an encrypted Gossip frame after session established
+	CommandType_SEALED CommandType = 7
+	// PEER_RECORDS carries signed peer records for discovery
+	CommandType_PEER_RECORDS CommandType = 8
+	// COMPRESSED carries a DEFLATE compressed Gossip frame in session,
+	// only if the receiver has announced FeatureDeflate
+	CommandType_COMPRESSED CommandType = 9
+	// PING and PONG carry a Ping message for keepalive and RTT measurement,
+	// PONG echoes the Ping message received.
+	CommandType_PING CommandType = 10
+	CommandType_PONG CommandType = 11
 )
 
 var CommandType_name = map[int32]string{
-	0: "NOP",
-	1: "KEY_AUTH_INIT",
-	2: "KEY_AUTH_CHALLENGE",
-	3: "KEY_AUTH_CHALLENGE_REPLY",
-	4: "CONSENSUS",
+	0:  "NOP",
+	1:  "KEY_AUTH_INIT",
+	2:  "KEY_AUTH_CHALLENGE",
+	3:  "KEY_AUTH_CHALLENGE_REPLY",
+	4:  "CONSENSUS",
+	5:  "CLIENT_REQUEST",
+	6:  "PROPOSAL",
+	7:  "SEALED",
+	8:  "PEER_RECORDS",
+	9:  "COMPRESSED",
+	10: "PING",
+	11: "PONG",
 }
 
 var CommandType_value = map[string]int32{
@@ -47,6 +67,13 @@ var CommandType_value = map[string]int32{
 	"KEY_AUTH_CHALLENGE":       2,
 	"KEY_AUTH_CHALLENGE_REPLY": 3,
 	"CONSENSUS":                4,
+	"CLIENT_REQUEST":           5,
+	"PROPOSAL":                 6,
+	"SEALED":                   7,
+	"PEER_RECORDS":             8,
+	"COMPRESSED":               9,
+	"PING":                     10,
+	"PONG":                     11,
 }
 
 func (x CommandType) String() string {
@@ -115,8 +142,16 @@ func (m *Gossip) GetMessage() []byte {
 
 type KeyAuthInit struct {
 	// client public key
-	X                    []byte   `protobuf:"bytes,1,opt,name=X,proto3" json:"X,omitempty"`
-	Y                    []byte   `protobuf:"bytes,2,opt,name=Y,proto3" json:"Y,omitempty"`
+	X []byte `protobuf:"bytes,1,opt,name=X,proto3" json:"X,omitempty"`
+	Y []byte `protobuf:"bytes,2,opt,name=Y,proto3" json:"Y,omitempty"`
+	// features supported by the client, as a bitmask
+	Features uint64 `protobuf:"varint,3,opt,name=Features,proto3" json:"Features,omitempty"`
+	// hello to check compatibility: gossip protocol version, consensus
+	// protocol version, the network identifier and the latest height
+	GossipVersion        uint32   `protobuf:"varint,4,opt,name=GossipVersion,proto3" json:"GossipVersion,omitempty"`
+	ProtocolVersion      uint32   `protobuf:"varint,5,opt,name=ProtocolVersion,proto3" json:"ProtocolVersion,omitempty"`
+	ChainID              []byte   `protobuf:"bytes,6,opt,name=ChainID,proto3" json:"ChainID,omitempty"`
+	Height               uint64   `protobuf:"varint,7,opt,name=Height,proto3" json:"Height,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -169,6 +204,41 @@ func (m *KeyAuthInit) GetY() []byte {
 	return nil
 }
 
+func (m *KeyAuthInit) GetFeatures() uint64 {
+	if m != nil {
+		return m.Features
+	}
+	return 0
+}
+
+func (m *KeyAuthInit) GetGossipVersion() uint32 {
+	if m != nil {
+		return m.GossipVersion
+	}
+	return 0
+}
+
+func (m *KeyAuthInit) GetProtocolVersion() uint32 {
+	if m != nil {
+		return m.ProtocolVersion
+	}
+	return 0
+}
+
+func (m *KeyAuthInit) GetChainID() []byte {
+	if m != nil {
+		return m.ChainID
+	}
+	return nil
+}
+
+func (m *KeyAuthInit) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
 type KeyAuthChallenge struct {
 	// server ephermal publickey for client authentication
 	X []byte `protobuf:"bytes,1,opt,name=X,proto3" json:"X,omitempty"`
@@ -281,36 +351,242 @@ func (m *KeyAuthChallengeReply) GetHMAC() []byte {
 	return nil
 }
 
+// PeerRecord is a signed (identity, address, timestamp) record announced
+// by the peer itself
+type PeerRecord struct {
+	// public key of the peer
+	X []byte `protobuf:"bytes,1,opt,name=X,proto3" json:"X,omitempty"`
+	Y []byte `protobuf:"bytes,2,opt,name=Y,proto3" json:"Y,omitempty"`
+	// the address to dial the peer
+	Address string `protobuf:"bytes,3,opt,name=Address,proto3" json:"Address,omitempty"`
+	// unix nano timestamp of the record, newer records replace older ones
+	Timestamp int64 `protobuf:"varint,4,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
+	// signature
+	R                    []byte   `protobuf:"bytes,5,opt,name=R,proto3" json:"R,omitempty"`
+	S                    []byte   `protobuf:"bytes,6,opt,name=S,proto3" json:"S,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PeerRecord) Reset()         { *m = PeerRecord{} }
+func (m *PeerRecord) String() string { return proto.CompactTextString(m) }
+func (*PeerRecord) ProtoMessage()    {}
+func (*PeerRecord) Descriptor() ([]byte, []int) {
+	return fileDescriptor_878fa4887b90140c, []int{4}
+}
+func (m *PeerRecord) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PeerRecord) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PeerRecord.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PeerRecord) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PeerRecord.Merge(m, src)
+}
+func (m *PeerRecord) XXX_Size() int {
+	return m.Size()
+}
+func (m *PeerRecord) XXX_DiscardUnknown() {
+	xxx_messageInfo_PeerRecord.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PeerRecord proto.InternalMessageInfo
+
+func (m *PeerRecord) GetX() []byte {
+	if m != nil {
+		return m.X
+	}
+	return nil
+}
+
+func (m *PeerRecord) GetY() []byte {
+	if m != nil {
+		return m.Y
+	}
+	return nil
+}
+
+func (m *PeerRecord) GetAddress() string {
+	if m != nil {
+		return m.Address
+	}
+	return ""
+}
+
+func (m *PeerRecord) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *PeerRecord) GetR() []byte {
+	if m != nil {
+		return m.R
+	}
+	return nil
+}
+
+func (m *PeerRecord) GetS() []byte {
+	if m != nil {
+		return m.S
+	}
+	return nil
+}
+
+type PeerRecords struct {
+	Records              []*PeerRecord `protobuf:"bytes,1,rep,name=Records,proto3" json:"Records,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *PeerRecords) Reset()         { *m = PeerRecords{} }
+func (m *PeerRecords) String() string { return proto.CompactTextString(m) }
+func (*PeerRecords) ProtoMessage()    {}
+func (*PeerRecords) Descriptor() ([]byte, []int) {
+	return fileDescriptor_878fa4887b90140c, []int{5}
+}
+func (m *PeerRecords) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PeerRecords) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PeerRecords.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PeerRecords) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PeerRecords.Merge(m, src)
+}
+func (m *PeerRecords) XXX_Size() int {
+	return m.Size()
+}
+func (m *PeerRecords) XXX_DiscardUnknown() {
+	xxx_messageInfo_PeerRecords.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PeerRecords proto.InternalMessageInfo
+
+func (m *PeerRecords) GetRecords() []*PeerRecord {
+	if m != nil {
+		return m.Records
+	}
+	return nil
+}
+
+type Ping struct {
+	// unix nano timestamp of the sender
+	Timestamp            int64    `protobuf:"varint,1,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Ping) Reset()         { *m = Ping{} }
+func (m *Ping) String() string { return proto.CompactTextString(m) }
+func (*Ping) ProtoMessage()    {}
+func (*Ping) Descriptor() ([]byte, []int) {
+	return fileDescriptor_878fa4887b90140c, []int{6}
+}
+func (m *Ping) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Ping) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Ping.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *Ping) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Ping.Merge(m, src)
+}
+func (m *Ping) XXX_Size() int {
+	return m.Size()
+}
+func (m *Ping) XXX_DiscardUnknown() {
+	xxx_messageInfo_Ping.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Ping proto.InternalMessageInfo
+
+func (m *Ping) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterEnum("agent.CommandType", CommandType_name, CommandType_value)
 	proto.RegisterType((*Gossip)(nil), "agent.Gossip")
 	proto.RegisterType((*KeyAuthInit)(nil), "agent.KeyAuthInit")
 	proto.RegisterType((*KeyAuthChallenge)(nil), "agent.KeyAuthChallenge")
 	proto.RegisterType((*KeyAuthChallengeReply)(nil), "agent.KeyAuthChallengeReply")
+	proto.RegisterType((*PeerRecord)(nil), "agent.PeerRecord")
+	proto.RegisterType((*PeerRecords)(nil), "agent.PeerRecords")
+	proto.RegisterType((*Ping)(nil), "agent.Ping")
 }
 
 func init() { proto.RegisterFile("gossip.proto", fileDescriptor_878fa4887b90140c) }
 
 var fileDescriptor_878fa4887b90140c = []byte{
-	// 285 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x91, 0xcd, 0x6a, 0x83, 0x50,
-	0x10, 0x85, 0x7b, 0x63, 0x1a, 0xc9, 0xc4, 0x94, 0xdb, 0x81, 0x16, 0x17, 0x41, 0x82, 0xab, 0xf4,
-	0x07, 0x17, 0xed, 0x13, 0x58, 0x91, 0x28, 0x31, 0x46, 0xd4, 0x40, 0x5c, 0x89, 0xa5, 0x17, 0x13,
-	0x30, 0x2a, 0xd5, 0x2e, 0x7c, 0xc3, 0x2e, 0xfb, 0x08, 0xc5, 0x27, 0x29, 0xbd, 0x98, 0xb4, 0xb4,
-	0x90, 0xdd, 0x9c, 0x8f, 0x6f, 0x0e, 0x0c, 0x03, 0x52, 0x5a, 0x54, 0xd5, 0xae, 0xd4, 0xca, 0xd7,
-	0xa2, 0x2e, 0xf0, 0x3c, 0x49, 0x59, 0x5e, 0xab, 0x1e, 0x0c, 0xe6, 0x1c, 0xe3, 0x3d, 0x88, 0x46,
-	0xb1, 0xdf, 0x27, 0xf9, 0x8b, 0x4c, 0xa6, 0x64, 0x76, 0xf1, 0x80, 0x1a, 0x57, 0xb4, 0x8e, 0x86,
-	0x4d, 0xc9, 0xfc, 0x83, 0x82, 0x32, 0x88, 0x4b, 0x56, 0x55, 0x49, 0xca, 0xe4, 0xde, 0x94, 0xcc,
-	0x24, 0xff, 0x10, 0xd5, 0x1b, 0x18, 0x2d, 0x58, 0xa3, 0xbf, 0xd5, 0x5b, 0x3b, 0xdf, 0xd5, 0x28,
-	0x01, 0xd9, 0xf0, 0x42, 0xc9, 0x27, 0x9b, 0xef, 0x14, 0x75, 0x0b, 0x24, 0x52, 0x1d, 0xa0, 0x9d,
-	0x6a, 0x6c, 0x93, 0x2c, 0x63, 0x79, 0xca, 0x4e, 0xf9, 0x38, 0x81, 0xe1, 0x51, 0x94, 0x05, 0x4e,
-	0x7f, 0x80, 0x7a, 0x07, 0x57, 0x7f, 0xdb, 0x7c, 0x56, 0x66, 0x0d, 0x22, 0xf4, 0xad, 0xa5, 0x6e,
-	0x74, 0xad, 0x7c, 0xbe, 0xcd, 0x61, 0xf4, 0xeb, 0x2e, 0x14, 0x41, 0x70, 0x57, 0x1e, 0x3d, 0xc3,
-	0x4b, 0x18, 0x2f, 0xcc, 0x28, 0xd6, 0xd7, 0xa1, 0x15, 0xdb, 0xae, 0x1d, 0x52, 0x82, 0xd7, 0x80,
-	0x47, 0x64, 0x58, 0xba, 0xe3, 0x98, 0xee, 0xdc, 0xa4, 0x3d, 0x9c, 0x80, 0xfc, 0x9f, 0xc7, 0xbe,
-	0xe9, 0x39, 0x11, 0x15, 0x70, 0x0c, 0x43, 0x63, 0xe5, 0x06, 0xa6, 0x1b, 0xac, 0x03, 0xda, 0x7f,
-	0x92, 0xde, 0x5b, 0x85, 0x7c, 0xb4, 0x0a, 0xf9, 0x6c, 0x15, 0xf2, 0x3c, 0xe0, 0x3f, 0x78, 0xfc,
-	0x0a, 0x00, 0x00, 0xff, 0xff, 0x96, 0x66, 0x6e, 0x1f, 0x93, 0x01, 0x00, 0x00,
+	// 528 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x93, 0xc1, 0x6e, 0x9b, 0x40,
+	0x10, 0x86, 0xbb, 0x31, 0x01, 0x7b, 0x8c, 0xd3, 0xcd, 0x48, 0x8d, 0x50, 0x15, 0x45, 0x16, 0xca,
+	0x01, 0x35, 0x55, 0x0e, 0xe9, 0xad, 0x37, 0x8a, 0xb7, 0x36, 0x0a, 0x01, 0xba, 0x90, 0x2a, 0x3e,
+	0x59, 0x34, 0x5e, 0x61, 0x24, 0x1b, 0x2c, 0x20, 0x07, 0x4b, 0x7d, 0xbf, 0xf6, 0x58, 0xf5, 0x09,
+	0x2a, 0x3f, 0x49, 0x05, 0xc6, 0x76, 0xe3, 0x4a, 0xb9, 0xcd, 0xff, 0xe9, 0x67, 0xe6, 0x9f, 0xd1,
+	0x02, 0x6a, 0x9c, 0x15, 0x45, 0xb2, 0xbc, 0x5e, 0xe6, 0x59, 0x99, 0xe1, 0x71, 0x14, 0x8b, 0xb4,
+	0xd4, 0x7d, 0x90, 0x87, 0x35, 0xc6, 0xf7, 0xa0, 0x58, 0xd9, 0x62, 0x11, 0xa5, 0x53, 0x8d, 0xf4,
+	0x89, 0x71, 0x72, 0x83, 0xd7, 0xb5, 0xe5, 0xba, 0xa1, 0xe1, 0x6a, 0x29, 0xf8, 0xd6, 0x82, 0x1a,
+	0x28, 0x77, 0xa2, 0x28, 0xa2, 0x58, 0x68, 0x47, 0x7d, 0x62, 0xa8, 0x7c, 0x2b, 0xf5, 0x1f, 0x04,
+	0xba, 0xb7, 0x62, 0x65, 0x3e, 0x95, 0x33, 0x3b, 0x4d, 0x4a, 0x54, 0x81, 0x3c, 0xd4, 0x1d, 0x55,
+	0x4e, 0x1e, 0x2a, 0x35, 0x6e, 0xbe, 0x20, 0x63, 0x7c, 0x0b, 0xed, 0xcf, 0x22, 0x2a, 0x9f, 0x72,
+	0x51, 0x68, 0xad, 0x3e, 0x31, 0x24, 0xbe, 0xd3, 0x78, 0x09, 0xbd, 0x4d, 0xb2, 0xaf, 0x22, 0x2f,
+	0x92, 0x2c, 0xd5, 0xa4, 0x3e, 0x31, 0x7a, 0xfc, 0x39, 0x44, 0x03, 0x5e, 0xfb, 0xd5, 0x3e, 0x8f,
+	0xd9, 0x7c, 0xeb, 0x3b, 0xae, 0x7d, 0x87, 0xb8, 0x4a, 0x6c, 0xcd, 0xa2, 0x24, 0xb5, 0x07, 0x9a,
+	0xbc, 0x49, 0xdc, 0x48, 0x3c, 0x03, 0x79, 0x24, 0x92, 0x78, 0x56, 0x6a, 0x4a, 0x9d, 0xa1, 0x51,
+	0xba, 0x03, 0xb4, 0x59, 0xc4, 0x9a, 0x45, 0xf3, 0xb9, 0x48, 0x63, 0xf1, 0xe2, 0x36, 0xe7, 0xd0,
+	0xd9, 0x19, 0xeb, 0x75, 0x54, 0xbe, 0x07, 0xfa, 0x15, 0xbc, 0x39, 0xec, 0xc6, 0xc5, 0x72, 0xbe,
+	0x42, 0x04, 0x69, 0x74, 0x67, 0x5a, 0x4d, 0xd7, 0xba, 0xd6, 0xbf, 0x03, 0xf8, 0x42, 0xe4, 0x5c,
+	0x3c, 0x66, 0xf9, 0xf4, 0xc5, 0xa1, 0x1a, 0x28, 0xe6, 0x74, 0x9a, 0x8b, 0x62, 0x73, 0xc1, 0x0e,
+	0xdf, 0xca, 0x2a, 0x4e, 0x98, 0x2c, 0x44, 0x51, 0x46, 0x8b, 0x65, 0x7d, 0xbc, 0x16, 0xdf, 0x83,
+	0xaa, 0x0b, 0xaf, 0x4f, 0xa5, 0x72, 0xc2, 0x2b, 0x15, 0x34, 0x67, 0x21, 0x81, 0xfe, 0x11, 0xba,
+	0xfb, 0xe9, 0x05, 0x5e, 0x81, 0xd2, 0x94, 0x1a, 0xe9, 0xb7, 0x8c, 0xee, 0xcd, 0x69, 0xf3, 0x32,
+	0xf6, 0x26, 0xbe, 0x75, 0xe8, 0x97, 0x20, 0xf9, 0x49, 0x1a, 0x3f, 0x9f, 0x4e, 0x0e, 0xa6, 0xbf,
+	0xfb, 0x4d, 0xa0, 0xfb, 0xcf, 0xbb, 0x42, 0x05, 0x5a, 0xae, 0xe7, 0xd3, 0x57, 0x78, 0x0a, 0xbd,
+	0x5b, 0x36, 0x9e, 0x98, 0xf7, 0xe1, 0x68, 0x62, 0xbb, 0x76, 0x48, 0x09, 0x9e, 0x01, 0xee, 0x90,
+	0x35, 0x32, 0x1d, 0x87, 0xb9, 0x43, 0x46, 0x8f, 0xf0, 0x1c, 0xb4, 0xff, 0xf9, 0x84, 0x33, 0xdf,
+	0x19, 0xd3, 0x16, 0xf6, 0xa0, 0x63, 0x79, 0x6e, 0xc0, 0xdc, 0xe0, 0x3e, 0xa0, 0x12, 0x22, 0x9c,
+	0x58, 0x8e, 0xcd, 0xdc, 0x70, 0xc2, 0xd9, 0x97, 0x7b, 0x16, 0x84, 0xf4, 0x18, 0x55, 0x68, 0xfb,
+	0xdc, 0xf3, 0xbd, 0xc0, 0x74, 0xa8, 0x8c, 0x00, 0x72, 0xc0, 0x4c, 0x87, 0x0d, 0xa8, 0x82, 0x14,
+	0x54, 0x9f, 0x31, 0x3e, 0xe1, 0xcc, 0xf2, 0xf8, 0x20, 0xa0, 0x6d, 0x3c, 0x01, 0xb0, 0xbc, 0x3b,
+	0x9f, 0xb3, 0x20, 0x60, 0x03, 0xda, 0xc1, 0x36, 0x48, 0xbe, 0xed, 0x0e, 0x29, 0xd4, 0x95, 0xe7,
+	0x0e, 0x69, 0xf7, 0x93, 0xfa, 0x73, 0x7d, 0x41, 0x7e, 0xad, 0x2f, 0xc8, 0x9f, 0xf5, 0x05, 0xf9,
+	0x26, 0xd7, 0xff, 0xd9, 0x87, 0xbf, 0x03, 0x00, 0x6d, 0xcb, 0xdb, 0x66, 0x77, 0x03, 0x00, 0x00,
 }
 
 func (m *Gossip) Marshal() (dAtA []byte, err error) {
@@ -376,6 +652,33 @@ func (m *KeyAuthInit) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Height != 0 {
+		i = encodeVarintGossip(dAtA, i, uint64(m.Height))
+		i--
+		dAtA[i] = 0x38
+	}
+	if len(m.ChainID) > 0 {
+		i -= len(m.ChainID)
+		copy(dAtA[i:], m.ChainID)
+		i = encodeVarintGossip(dAtA, i, uint64(len(m.ChainID)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if m.ProtocolVersion != 0 {
+		i = encodeVarintGossip(dAtA, i, uint64(m.ProtocolVersion))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.GossipVersion != 0 {
+		i = encodeVarintGossip(dAtA, i, uint64(m.GossipVersion))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Features != 0 {
+		i = encodeVarintGossip(dAtA, i, uint64(m.Features))
+		i--
+		dAtA[i] = 0x18
+	}
 	if len(m.Y) > 0 {
 		i -= len(m.Y)
 		copy(dAtA[i:], m.Y)
@@ -475,6 +778,146 @@ func (m *KeyAuthChallengeReply) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *PeerRecord) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PeerRecord) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PeerRecord) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.S) > 0 {
+		i -= len(m.S)
+		copy(dAtA[i:], m.S)
+		i = encodeVarintGossip(dAtA, i, uint64(len(m.S)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.R) > 0 {
+		i -= len(m.R)
+		copy(dAtA[i:], m.R)
+		i = encodeVarintGossip(dAtA, i, uint64(len(m.R)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Timestamp != 0 {
+		i = encodeVarintGossip(dAtA, i, uint64(m.Timestamp))
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.Address) > 0 {
+		i -= len(m.Address)
+		copy(dAtA[i:], m.Address)
+		i = encodeVarintGossip(dAtA, i, uint64(len(m.Address)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Y) > 0 {
+		i -= len(m.Y)
+		copy(dAtA[i:], m.Y)
+		i = encodeVarintGossip(dAtA, i, uint64(len(m.Y)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.X) > 0 {
+		i -= len(m.X)
+		copy(dAtA[i:], m.X)
+		i = encodeVarintGossip(dAtA, i, uint64(len(m.X)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PeerRecords) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PeerRecords) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PeerRecords) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Records) > 0 {
+		for iNdEx := len(m.Records) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Records[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintGossip(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Ping) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Ping) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Ping) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Timestamp != 0 {
+		i = encodeVarintGossip(dAtA, i, uint64(m.Timestamp))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintGossip(dAtA []byte, offset int, v uint64) int {
 	offset -= sovGossip(v)
 	base := offset
@@ -519,6 +962,22 @@ func (m *KeyAuthInit) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovGossip(uint64(l))
 	}
+	if m.Features != 0 {
+		n += 1 + sovGossip(uint64(m.Features))
+	}
+	if m.GossipVersion != 0 {
+		n += 1 + sovGossip(uint64(m.GossipVersion))
+	}
+	if m.ProtocolVersion != 0 {
+		n += 1 + sovGossip(uint64(m.ProtocolVersion))
+	}
+	l = len(m.ChainID)
+	if l > 0 {
+		n += 1 + l + sovGossip(uint64(l))
+	}
+	if m.Height != 0 {
+		n += 1 + sovGossip(uint64(m.Height))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -565,10 +1024,78 @@ func (m *KeyAuthChallengeReply) Size() (n int) {
 	return n
 }
 
-func sovGossip(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozGossip(x uint64) (n int) {
+func (m *PeerRecord) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.X)
+	if l > 0 {
+		n += 1 + l + sovGossip(uint64(l))
+	}
+	l = len(m.Y)
+	if l > 0 {
+		n += 1 + l + sovGossip(uint64(l))
+	}
+	l = len(m.Address)
+	if l > 0 {
+		n += 1 + l + sovGossip(uint64(l))
+	}
+	if m.Timestamp != 0 {
+		n += 1 + sovGossip(uint64(m.Timestamp))
+	}
+	l = len(m.R)
+	if l > 0 {
+		n += 1 + l + sovGossip(uint64(l))
+	}
+	l = len(m.S)
+	if l > 0 {
+		n += 1 + l + sovGossip(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *PeerRecords) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Records) > 0 {
+		for _, e := range m.Records {
+			l = e.Size()
+			n += 1 + l + sovGossip(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Ping) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Timestamp != 0 {
+		n += 1 + sovGossip(uint64(m.Timestamp))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func sovGossip(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozGossip(x uint64) (n int) {
 	return sovGossip(uint64((x << 1) ^ uint64((int64(x) >> 63))))
 }
 func (m *Gossip) Unmarshal(dAtA []byte) error {
@@ -775,6 +1302,116 @@ func (m *KeyAuthInit) Unmarshal(dAtA []byte) error {
 				m.Y = []byte{}
 			}
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Features", wireType)
+			}
+			m.Features = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGossip
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Features |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field GossipVersion", wireType)
+			}
+			m.GossipVersion = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGossip
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.GossipVersion |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ProtocolVersion", wireType)
+			}
+			m.ProtocolVersion = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGossip
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ProtocolVersion |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChainID", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGossip
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthGossip
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthGossip
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ChainID = append(m.ChainID[:0], dAtA[iNdEx:postIndex]...)
+			if m.ChainID == nil {
+				m.ChainID = []byte{}
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Height", wireType)
+			}
+			m.Height = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGossip
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Height |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipGossip(dAtA[iNdEx:])
@@ -1044,6 +1681,408 @@ func (m *KeyAuthChallengeReply) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *PeerRecord) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowGossip
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PeerRecord: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PeerRecord: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field X", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGossip
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthGossip
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthGossip
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.X = append(m.X[:0], dAtA[iNdEx:postIndex]...)
+			if m.X == nil {
+				m.X = []byte{}
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Y", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGossip
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthGossip
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthGossip
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Y = append(m.Y[:0], dAtA[iNdEx:postIndex]...)
+			if m.Y == nil {
+				m.Y = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Address", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGossip
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthGossip
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthGossip
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Address = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timestamp", wireType)
+			}
+			m.Timestamp = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGossip
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Timestamp |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field R", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGossip
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthGossip
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthGossip
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.R = append(m.R[:0], dAtA[iNdEx:postIndex]...)
+			if m.R == nil {
+				m.R = []byte{}
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field S", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGossip
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthGossip
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthGossip
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.S = append(m.S[:0], dAtA[iNdEx:postIndex]...)
+			if m.S == nil {
+				m.S = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipGossip(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthGossip
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthGossip
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *PeerRecords) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowGossip
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: PeerRecords: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: PeerRecords: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Records", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGossip
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthGossip
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthGossip
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Records = append(m.Records, &PeerRecord{})
+			if err := m.Records[len(m.Records)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipGossip(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthGossip
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthGossip
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Ping) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowGossip
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Ping: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Ping: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timestamp", wireType)
+			}
+			m.Timestamp = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowGossip
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Timestamp |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipGossip(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthGossip
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthGossip
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipGossip(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"bytes"
+
+	"github.com/BDLS-bft/bdls"
+)
+
+// GossipVersion is the version of gossip protocol, it's announced in
+// KeyAuthInit along with the consensus protocol version, the chain ID and the
+// latest height, peers of incompatible versions or on other networks are
+// rejected before authentication. The hellos are sent in plaintext, and mixed
+// into session keys, so a hello tampered in transit breaks the session.
+const GossipVersion = 1
+
+// fillHello fills the hello fields of KeyAuthInit
+func (agent *TCPAgent) fillHello(auth *KeyAuthInit, height uint64) {
+	auth.GossipVersion = GossipVersion
+	auth.ProtocolVersion = bdls.ProtocolVersion
+	auth.ChainID = agent.consensus.ChainID()
+	auth.Height = height
+}
+
+// checkHello checks the hello fields announced by a peer, as both sides
+// announce and check, an incompatible peer detects the same reason.
+func (agent *TCPAgent) checkHello(auth *KeyAuthInit) error {
+	if auth.GossipVersion != GossipVersion {
+		return ErrGossipVersion
+	}
+
+	if auth.ProtocolVersion != bdls.ProtocolVersion {
+		return ErrProtocolVersion
+	}
+
+	if !bytes.Equal(auth.ChainID, agent.consensus.ChainID()) {
+		return ErrChainID
+	}
+	return nil
+}
+
+// Height returns the latest height announced by the peer in handshake, it
+// can be compared with the local height to detect lagging at connect time.
+func (p *TCPPeer) Height() uint64 {
+	p.Lock()
+	defer p.Unlock()
+	return p.peerHeight
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// sendKeyAuthInit connects to the agent with a pipe and announces my key with
+// the hello, returns the peer of the agent side.
+func sendKeyAuthInit(t *testing.T, agent *TCPAgent, auth *KeyAuthInit) *TCPPeer {
+	c1, c2 := net.Pipe()
+	p := NewTCPPeer(c1, agent)
+	assert.True(t, agent.AddPeer(p))
+	go io.Copy(io.Discard, c2)
+
+	bts, err := proto.Marshal(auth)
+	assert.Nil(t, err)
+	frame, err := proto.Marshal(&Gossip{Command: CommandType_KEY_AUTH_INIT, Message: bts})
+	assert.Nil(t, err)
+
+	msgLength := make([]byte, MessageLength)
+	binary.LittleEndian.PutUint32(msgLength, uint32(len(frame)))
+	_, err = c2.Write(append(msgLength, frame...))
+	assert.Nil(t, err)
+	return p
+}
+
+func TestHello(t *testing.T) {
+	agents := createAgents(t, 2)
+	defer agents[0].Close()
+	defer agents[1].Close()
+
+	newAuth := func() *KeyAuthInit {
+		auth := new(KeyAuthInit)
+		auth.X = agents[1].privateKey.PublicKey.X.Bytes()
+		auth.Y = agents[1].privateKey.PublicKey.Y.Bytes()
+		agents[1].fillHello(auth, 5)
+		return auth
+	}
+
+	// compatible peers announce the latest height
+	p := sendKeyAuthInit(t, agents[0], newAuth())
+	deadline := time.Now().Add(5 * time.Second)
+	for p.Height() != 5 {
+		if time.Now().After(deadline) {
+			t.Fatal("hello has not been received")
+		}
+		<-time.After(10 * time.Millisecond)
+	}
+	assert.Equal(t, uint64(5), p.Stats().Height)
+	p.Close()
+
+	// incompatible peers are rejected with the reason
+	tests := []struct {
+		modify func(*KeyAuthInit)
+		err    error
+	}{
+		{func(auth *KeyAuthInit) { auth.GossipVersion = 0 }, ErrGossipVersion},
+		{func(auth *KeyAuthInit) { auth.ProtocolVersion = bdls.ProtocolVersion + 1 }, ErrProtocolVersion},
+		{func(auth *KeyAuthInit) { auth.ChainID = []byte("other") }, ErrChainID},
+	}
+
+	for _, test := range tests {
+		auth := newAuth()
+		test.modify(auth)
+		p := sendKeyAuthInit(t, agents[0], auth)
+		waitClosed(t, p)
+		stats := p.Stats()
+		assert.Equal(t, test.err.Error(), stats.LastError)
+		assert.Equal(t, "failed", stats.PeerAuth)
+		assert.Zero(t, stats.Score)
+	}
+}
@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	proto "github.com/gogo/protobuf/proto"
+)
+
+const (
+	// DefaultPingInterval is the default interval to ping a peer, it's well
+	// below the read timeout to keep quiet connections alive.
+	DefaultPingInterval = 10 * time.Second
+
+	// RTTWindow is the number of recent RTT samples kept for each peer
+	RTTWindow = 16
+
+	// MinAutoLatency is the minimum latency set to consensus from RTTs
+	MinAutoLatency = 10 * time.Millisecond
+)
+
+// RTTStats is the round-trip time statistics of recent pings to a peer
+type RTTStats struct {
+	Samples int // number of samples in window
+	Last    time.Duration
+	Min     time.Duration
+	Max     time.Duration
+	Mean    time.Duration
+}
+
+// SetPingInterval sets the interval to ping new connections, 0 to disable.
+func (agent *TCPAgent) SetPingInterval(interval time.Duration) {
+	agent.policyLock.Lock()
+	defer agent.policyLock.Unlock()
+	agent.pingInterval = interval
+}
+
+// SetAutoLatency sets the consensus latency from the percentile(0, 100] of
+// RTTs measured to all peers, the latency is half of the RTT percentile, and
+// not less than MinAutoLatency. 0 to disable.
+func (agent *TCPAgent) SetAutoLatency(percentile float64) {
+	agent.policyLock.Lock()
+	defer agent.policyLock.Unlock()
+	agent.latencyPercentile = percentile
+}
+
+// updateLatency sets the consensus latency from RTTs if enabled
+func (agent *TCPAgent) updateLatency() {
+	agent.policyLock.Lock()
+	percentile := agent.latencyPercentile
+	agent.policyLock.Unlock()
+	if percentile <= 0 {
+		return
+	}
+
+	agent.Lock()
+	defer agent.Unlock()
+	var samples []time.Duration
+	for _, p := range agent.peers {
+		samples = append(samples, p.rttSamples()...)
+	}
+	if len(samples) == 0 {
+		return
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	idx := int(math.Ceil(percentile/100*float64(len(samples)))) - 1
+	if idx < 0 {
+		idx = 0
+	} else if idx >= len(samples) {
+		idx = len(samples) - 1
+	}
+
+	latency := samples[idx] / 2
+	if latency < MinAutoLatency {
+		latency = MinAutoLatency
+	}
+	agent.latency = latency
+	agent.consensus.SetLatency(latency)
+}
+
+// pingLoop pings the peer periodically after session established
+func (p *TCPPeer) pingLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.ping()
+		case <-p.die:
+			return
+		}
+	}
+}
+
+// ping sends a ping with current timestamp, a ping awaiting pong will be
+// replaced.
+func (p *TCPPeer) ping() {
+	p.Lock()
+	defer p.Unlock()
+	if p.session == nil {
+		return
+	}
+
+	p.lastPing = time.Now().UnixNano()
+	bts, err := proto.Marshal(&Ping{Timestamp: p.lastPing})
+	if err != nil {
+		panic(err)
+	}
+	p.sendControlMessage(CommandType_PING, bts)
+}
+
+// handlePing echoes the ping back as pong
+func (p *TCPPeer) handlePing(bts []byte) error {
+	var m Ping
+	if err := proto.Unmarshal(bts, &m); err != nil {
+		return err
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	p.sendControlMessage(CommandType_PONG, bts)
+	return nil
+}
+
+// handlePong records the RTT if it replies the last ping
+func (p *TCPPeer) handlePong(bts []byte, now time.Time) error {
+	var m Ping
+	if err := proto.Unmarshal(bts, &m); err != nil {
+		return err
+	}
+
+	p.Lock()
+	if m.Timestamp == 0 || m.Timestamp != p.lastPing {
+		p.Unlock()
+		return nil
+	}
+	p.lastPing = 0
+
+	rtt := now.Sub(time.Unix(0, m.Timestamp))
+	if len(p.rtts) < RTTWindow {
+		p.rtts = append(p.rtts, rtt)
+	} else {
+		p.rtts[p.rttNext] = rtt
+	}
+	p.rttNext = (p.rttNext + 1) % RTTWindow
+	p.Unlock()
+
+	p.agent.updateLatency()
+	return nil
+}
+
+// sendControlMessage enqueues a control message of the highest priority,
+// the lock must be held.
+func (p *TCPPeer) sendControlMessage(command CommandType, bts []byte) {
+	out, err := proto.Marshal(&Gossip{Command: command, Message: bts})
+	if err != nil {
+		panic(err)
+	}
+
+	if p.queue.push(queuedFrame{frame: out}, priorityControl) {
+		p.notifyAgentMessage()
+	}
+}
+
+// rttSamples returns a copy of RTT samples in window
+func (p *TCPPeer) rttSamples() []time.Duration {
+	p.Lock()
+	defer p.Unlock()
+	samples := make([]time.Duration, len(p.rtts))
+	copy(samples, p.rtts)
+	return samples
+}
+
+// RTTStats returns the RTT statistics of recent pings
+func (p *TCPPeer) RTTStats() (stats RTTStats) {
+	p.Lock()
+	defer p.Unlock()
+	stats.Samples = len(p.rtts)
+	if stats.Samples == 0 {
+		return
+	}
+
+	stats.Last = p.rtts[(p.rttNext+RTTWindow-1)%RTTWindow]
+	stats.Min = p.rtts[0]
+	var sum time.Duration
+	for _, rtt := range p.rtts {
+		if rtt < stats.Min {
+			stats.Min = rtt
+		}
+		if rtt > stats.Max {
+			stats.Max = rtt
+		}
+		sum += rtt
+	}
+	stats.Mean = sum / time.Duration(stats.Samples)
+	return
+}
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRTTStats(t *testing.T) {
+	agents := createAgents(t, 2)
+	for _, agent := range agents {
+		defer agent.Close()
+		agent.SetPingInterval(0)
+	}
+	p, _ := connectPipe(t, agents[0], agents[1])
+
+	pong := func(ts int64, rtt time.Duration) {
+		bts, err := proto.Marshal(&Ping{Timestamp: ts})
+		assert.Nil(t, err)
+		assert.Nil(t, p.handlePong(bts, time.Unix(0, ts).Add(rtt)))
+	}
+
+	// pongs not replying the last ping are ignored
+	p.lastPing = 100
+	pong(200, time.Second)
+	assert.Equal(t, 0, p.RTTStats().Samples)
+
+	for i := 1; i <= RTTWindow+2; i++ {
+		p.lastPing = int64(i)
+		pong(int64(i), time.Duration(i)*time.Millisecond)
+	}
+	pong(RTTWindow+2, time.Second)
+
+	stats := p.RTTStats()
+	assert.Equal(t, RTTWindow, stats.Samples)
+	assert.Equal(t, time.Duration(RTTWindow+2)*time.Millisecond, stats.Last)
+	assert.Equal(t, 3*time.Millisecond, stats.Min)
+	assert.Equal(t, time.Duration(RTTWindow+2)*time.Millisecond, stats.Max)
+	assert.Equal(t, (3+RTTWindow+2)*time.Millisecond/2, stats.Mean)
+}
+
+func TestKeepaliveLatency(t *testing.T) {
+	agents := createAgents(t, 2)
+	for _, agent := range agents {
+		defer agent.Close()
+		agent.SetPingInterval(20 * time.Millisecond)
+	}
+	agents[0].SetAutoLatency(90)
+	p, _ := connectPipe(t, agents[0], agents[1])
+
+	deadline := time.Now().Add(5 * time.Second)
+	for p.RTTStats().Samples < 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("pings have not been replied in time")
+		}
+		<-time.After(10 * time.Millisecond)
+	}
+
+	agents[0].Lock()
+	latency := agents[0].latency
+	agents[0].Unlock()
+	assert.True(t, latency >= MinAutoLatency)
+	assert.True(t, latency < time.Second)
+}
@@ -0,0 +1,173 @@
+package agent
+
+import (
+	"github.com/BDLS-bft/bdls"
+	proto "github.com/gogo/protobuf/proto"
+)
+
+const (
+	// DefaultQueueMessages is the default maximum number of messages queued
+	// to send to a peer
+	DefaultQueueMessages = 4096
+
+	// DefaultQueueBytes is the default maximum bytes queued to send to a
+	// peer(64MB)
+	DefaultQueueBytes = 64 * 1024 * 1024
+)
+
+// priorities of outgoing messages, smaller is sent first
+const (
+	priorityControl     = iota // ping and pong
+	priorityDecide             // <decide>
+	priorityLock               // <lock>, <commit>, <lockrelease>
+	prioritySelect             // <select>, <resync>
+	priorityRoundChange        // <roundchange>
+	priorityAgent              // proposals, client requests and peer records
+	numPriorities
+)
+
+// messagePriority returns the priority of a consensus message type
+func messagePriority(t bdls.MessageType) int {
+	switch t {
+	case bdls.MessageType_Decide:
+		return priorityDecide
+	case bdls.MessageType_Lock, bdls.MessageType_Commit, bdls.MessageType_LockRelease:
+		return priorityLock
+	case bdls.MessageType_Select, bdls.MessageType_Resync:
+		return prioritySelect
+	default:
+		return priorityRoundChange
+	}
+}
+
+// QueueStats is the statistics of a peer's outgoing queue
+type QueueStats struct {
+	Messages   int    // messages queued
+	Bytes      int    // bytes queued
+	Dropped    uint64 // messages dropped as the queue is full
+	Superseded uint64 // <roundchange> messages superseded by newer ones
+}
+
+// queuedFrame is a plaintext gossip frame awaiting to be sealed and sent
+type queuedFrame struct {
+	frame       []byte
+	consensus   bool             // frame is a well-formed consensus message
+	mtype       bdls.MessageType // type of the consensus message
+	roundChange bool             // frame is a <roundchange> message
+	height      uint64
+	round       uint64
+}
+
+// sendQueue is a bounded priority queue of outgoing frames, frames of the same
+// priority are sent in order.
+type sendQueue struct {
+	levels      [numPriorities][]queuedFrame
+	maxMessages int
+	maxBytes    int
+	stats       QueueStats
+}
+
+// newSendQueue creates a queue with limits
+func newSendQueue(maxMessages int, maxBytes int) *sendQueue {
+	q := new(sendQueue)
+	q.maxMessages = maxMessages
+	q.maxBytes = maxBytes
+	return q
+}
+
+// newConsensusFrame encapsulates a consensus message as a gossip frame, and
+// returns the priority of the message.
+func newConsensusFrame(out []byte) (f queuedFrame, priority int) {
+	frame, err := proto.Marshal(&Gossip{Command: CommandType_CONSENSUS, Message: out})
+	if err != nil {
+		panic(err)
+	}
+	f.frame = frame
+
+	// classify the message, malformed messages are sent as <roundchange>
+	// without superseding others.
+	m, ok := decodeConsensusMessage(out)
+	if !ok {
+		return f, priorityRoundChange
+	}
+
+	f.consensus = true
+	f.mtype = m.Type
+	f.roundChange = m.Type == bdls.MessageType_RoundChange
+	f.height = m.Height
+	f.round = m.Round
+	return f, messagePriority(m.Type)
+}
+
+// decodeConsensusMessage decodes the message in an encoded SignedProto
+// without verification
+func decodeConsensusMessage(bts []byte) (*bdls.Message, bool) {
+	var sp bdls.SignedProto
+	if err := proto.Unmarshal(bts, &sp); err != nil {
+		return nil, false
+	}
+	m := new(bdls.Message)
+	if err := proto.Unmarshal(sp.Message, m); err != nil {
+		return nil, false
+	}
+	return m, true
+}
+
+// push enqueues a frame, a <roundchange> frame supersedes queued ones of
+// the same or earlier rounds. If the queue is full, frames of lower priority
+// will be dropped to make room, or the frame itself will be dropped, returns
+// false if the frame is dropped.
+func (q *sendQueue) push(f queuedFrame, priority int) bool {
+	if f.roundChange {
+		level := q.levels[priority][:0]
+		for _, queued := range q.levels[priority] {
+			if queued.roundChange && (queued.height < f.height || (queued.height == f.height && queued.round <= f.round)) {
+				q.stats.Messages--
+				q.stats.Bytes -= len(queued.frame)
+				q.stats.Superseded++
+				continue
+			}
+			level = append(level, queued)
+		}
+		q.levels[priority] = level
+	}
+
+	// make room by dropping the oldest frames of the lowest priority
+	for q.stats.Messages+1 > q.maxMessages || q.stats.Bytes+len(f.frame) > q.maxBytes {
+		lowest := numPriorities - 1
+		for lowest > priority && len(q.levels[lowest]) == 0 {
+			lowest--
+		}
+
+		if lowest <= priority {
+			q.stats.Dropped++
+			return false
+		}
+
+		q.stats.Messages--
+		q.stats.Bytes -= len(q.levels[lowest][0].frame)
+		q.stats.Dropped++
+		q.levels[lowest][0] = queuedFrame{}
+		q.levels[lowest] = q.levels[lowest][1:]
+	}
+
+	q.levels[priority] = append(q.levels[priority], f)
+	q.stats.Messages++
+	q.stats.Bytes += len(f.frame)
+	return true
+}
+
+// pop dequeues the frame of the highest priority
+func (q *sendQueue) pop() (queuedFrame, bool) {
+	for i := range q.levels {
+		if len(q.levels[i]) > 0 {
+			f := q.levels[i][0]
+			q.levels[i][0] = queuedFrame{}
+			q.levels[i] = q.levels[i][1:]
+			q.stats.Messages--
+			q.stats.Bytes -= len(f.frame)
+			return f, true
+		}
+	}
+	return queuedFrame{}, false
+}
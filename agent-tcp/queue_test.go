@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"testing"
+
+	"github.com/BDLS-bft/bdls"
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// createQueuedFrame creates a signed consensus message as a queued frame
+func createQueuedFrame(t *testing.T, privateKey *ecdsa.PrivateKey, mtype bdls.MessageType, height uint64, round uint64) (queuedFrame, int) {
+	m := bdls.Message{Type: mtype, Height: height, Round: round}
+	sp := new(bdls.SignedProto)
+	sp.Sign(&m, privateKey)
+	out, err := proto.Marshal(sp)
+	assert.Nil(t, err)
+	return newConsensusFrame(out)
+}
+
+// popMessage pops a frame and decodes the consensus message
+func popMessage(t *testing.T, q *sendQueue) *bdls.Message {
+	f, ok := q.pop()
+	assert.True(t, ok)
+	var g Gossip
+	assert.Nil(t, proto.Unmarshal(f.frame, &g))
+	assert.Equal(t, CommandType_CONSENSUS, g.Command)
+	var sp bdls.SignedProto
+	assert.Nil(t, proto.Unmarshal(g.Message, &sp))
+	m := new(bdls.Message)
+	assert.Nil(t, proto.Unmarshal(sp.Message, m))
+	assert.True(t, f.consensus)
+	assert.Equal(t, m.Type, f.mtype)
+	return m
+}
+
+func TestSendQueuePriority(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	q := newSendQueue(DefaultQueueMessages, DefaultQueueBytes)
+
+	assert.True(t, q.push(createQueuedFrame(t, privateKey, bdls.MessageType_RoundChange, 1, 0)))
+	assert.True(t, q.push(createQueuedFrame(t, privateKey, bdls.MessageType_Commit, 1, 0)))
+	assert.True(t, q.push(createQueuedFrame(t, privateKey, bdls.MessageType_Select, 1, 0)))
+	assert.True(t, q.push(createQueuedFrame(t, privateKey, bdls.MessageType_Decide, 1, 0)))
+	// repeated <roundchange> of the same round supersedes
+	assert.True(t, q.push(createQueuedFrame(t, privateKey, bdls.MessageType_RoundChange, 1, 0)))
+	assert.True(t, q.push(createQueuedFrame(t, privateKey, bdls.MessageType_RoundChange, 1, 1)))
+	// but not the later rounds
+	assert.True(t, q.push(createQueuedFrame(t, privateKey, bdls.MessageType_RoundChange, 1, 0)))
+	assert.Equal(t, 5, q.stats.Messages)
+	assert.Equal(t, uint64(2), q.stats.Superseded)
+
+	assert.Equal(t, bdls.MessageType_Decide, popMessage(t, q).Type)
+	assert.Equal(t, bdls.MessageType_Commit, popMessage(t, q).Type)
+	assert.Equal(t, bdls.MessageType_Select, popMessage(t, q).Type)
+	m := popMessage(t, q)
+	assert.Equal(t, bdls.MessageType_RoundChange, m.Type)
+	assert.Equal(t, uint64(1), m.Round)
+	m = popMessage(t, q)
+	assert.Equal(t, uint64(0), m.Round)
+
+	_, ok := q.pop()
+	assert.False(t, ok)
+	assert.Equal(t, 0, q.stats.Messages)
+	assert.Equal(t, 0, q.stats.Bytes)
+}
+
+func TestSendQueueLimits(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	q := newSendQueue(2, DefaultQueueBytes)
+
+	// lower priority messages are dropped to make room
+	assert.True(t, q.push(queuedFrame{frame: []byte("agent")}, priorityAgent))
+	assert.True(t, q.push(createQueuedFrame(t, privateKey, bdls.MessageType_Commit, 1, 0)))
+	assert.True(t, q.push(createQueuedFrame(t, privateKey, bdls.MessageType_Decide, 1, 0)))
+	assert.Equal(t, uint64(1), q.stats.Dropped)
+
+	// or the message itself
+	assert.False(t, q.push(createQueuedFrame(t, privateKey, bdls.MessageType_Lock, 1, 0)))
+	assert.False(t, q.push(queuedFrame{frame: []byte("agent")}, priorityAgent))
+	assert.Equal(t, uint64(3), q.stats.Dropped)
+	assert.Equal(t, 2, q.stats.Messages)
+
+	assert.Equal(t, bdls.MessageType_Decide, popMessage(t, q).Type)
+	assert.Equal(t, bdls.MessageType_Commit, popMessage(t, q).Type)
+
+	// bytes limit
+	q = newSendQueue(DefaultQueueMessages, 10)
+	assert.True(t, q.push(queuedFrame{frame: make([]byte, 10)}, priorityAgent))
+	assert.False(t, q.push(queuedFrame{frame: make([]byte, 1)}, priorityAgent))
+	assert.Equal(t, 10, q.stats.Bytes)
+}
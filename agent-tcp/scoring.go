@@ -0,0 +1,226 @@
+package agent
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+)
+
+const (
+	// PenaltyMalformed is the penalty for malformed frames and protocol
+	// violations
+	PenaltyMalformed = 20
+
+	// PenaltyAuthFailed is the penalty for failed public key authentication
+	PenaltyAuthFailed = 50
+
+	// PenaltyInvalidMessage is the penalty for consensus messages rejected
+	// with signature or participant errors
+	PenaltyInvalidMessage = 10
+
+	// PenaltyInvalidProposal is the penalty for proposals which failed
+	// validation, it's small as honest peers at other heights may gossip
+	// proposals invalid at our height
+	PenaltyInvalidProposal = 2
+
+	// DefaultBanThreshold is the default score to ban a peer
+	DefaultBanThreshold = 100
+
+	// DefaultBanDuration is the default period to ban a peer
+	DefaultBanDuration = 10 * time.Minute
+
+	// ScoreDecayPerMinute is the score forgiven per minute
+	ScoreDecayPerMinute = 10
+
+	// ScorePruneInterval is the interval to remove scores which have decayed
+	// to zero and are not banned
+	ScorePruneInterval = time.Minute
+)
+
+// scoreKey identifies a peer by its identity once authenticated, or by its
+// IP address before.
+type scoreKey struct {
+	identity bdls.Identity
+	ip       string
+}
+
+// peerScore is the misbehavior score of a peer
+type peerScore struct {
+	score       float64
+	updated     time.Time
+	bannedUntil time.Time
+}
+
+// decay forgives the score since last update
+func (s *peerScore) decay(now time.Time) {
+	s.score -= now.Sub(s.updated).Minutes() * ScoreDecayPerMinute
+	if s.score < 0 {
+		s.score = 0
+	}
+	s.updated = now
+}
+
+// SetBanPolicy sets the score threshold to ban a peer and the ban duration
+func (agent *TCPAgent) SetBanPolicy(threshold float64, duration time.Duration) {
+	agent.scoreLock.Lock()
+	defer agent.scoreLock.Unlock()
+	agent.banThreshold = threshold
+	agent.banDuration = duration
+}
+
+// Ban bans an identity for the duration, and disconnects it.
+func (agent *TCPAgent) Ban(identity bdls.Identity, duration time.Duration) {
+	now := time.Now()
+	agent.scoreLock.Lock()
+	agent.score(scoreKey{identity: identity}, now).bannedUntil = now.Add(duration)
+	agent.scoreLock.Unlock()
+	agent.closeBanned(identity)
+}
+
+// score returns the score of the key, the score lock must be held.
+func (agent *TCPAgent) score(key scoreKey, now time.Time) *peerScore {
+	agent.pruneScores(now)
+	s, ok := agent.scores[key]
+	if !ok {
+		s = &peerScore{updated: now}
+		agent.scores[key] = s
+	}
+	s.decay(now)
+	return s
+}
+
+// pruneScores removes scores which have decayed to zero and whose bans have
+// expired, at most once per ScorePruneInterval, as scores of IP addresses
+// would grow without bound otherwise. The score lock must be held.
+func (agent *TCPAgent) pruneScores(now time.Time) {
+	if now.Sub(agent.scoresPruned) < ScorePruneInterval {
+		return
+	}
+	agent.scoresPruned = now
+
+	for key, s := range agent.scores {
+		s.decay(now)
+		if s.score == 0 && !now.Before(s.bannedUntil) {
+			delete(agent.scores, key)
+		}
+	}
+}
+
+// peerScoreKey returns the score key of a connection
+func peerScoreKey(p *TCPPeer) scoreKey {
+	if pubkey := p.GetPublicKey(); pubkey != nil {
+		return scoreKey{identity: bdls.DefaultPubKeyToIdentity(pubkey)}
+	}
+
+	return addressKey(p.RemoteAddr().String())
+}
+
+// addressKey returns the score key of an address
+func addressKey(address string) scoreKey {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return scoreKey{ip: host}
+	}
+	return scoreKey{ip: address}
+}
+
+// penalize adds penalty to the peer's score, the peer will be banned and
+// disconnected if the score reaches threshold.
+func (agent *TCPAgent) penalize(p *TCPPeer, penalty float64) {
+	key := peerScoreKey(p)
+	now := time.Now()
+
+	agent.scoreLock.Lock()
+	s := agent.score(key, now)
+	s.score += penalty
+	banned := s.score >= agent.banThreshold
+	if banned {
+		s.score = 0
+		s.bannedUntil = now.Add(agent.banDuration)
+	}
+	agent.scoreLock.Unlock()
+
+	if banned {
+		p.Close()
+		if key.ip == "" {
+			agent.closeBanned(key.identity)
+		}
+	}
+}
+
+// closeBanned disconnects all connections to the identity
+func (agent *TCPAgent) closeBanned(identity bdls.Identity) {
+	agent.Lock()
+	peers := make([]*TCPPeer, len(agent.peers))
+	copy(peers, agent.peers)
+	agent.Unlock()
+
+	for _, p := range peers {
+		if pubkey := p.GetPublicKey(); pubkey != nil && bdls.DefaultPubKeyToIdentity(pubkey) == identity {
+			p.Close()
+		}
+	}
+}
+
+// banned checks whether the key has been banned
+func (agent *TCPAgent) banned(key scoreKey) bool {
+	now := time.Now()
+	agent.scoreLock.Lock()
+	defer agent.scoreLock.Unlock()
+	s, ok := agent.scores[key]
+	if !ok {
+		return false
+	}
+	s.decay(now)
+	return now.Before(s.bannedUntil)
+}
+
+// peerScore returns the current score of the connection
+func (agent *TCPAgent) peerScore(p *TCPPeer) float64 {
+	key := peerScoreKey(p)
+	agent.scoreLock.Lock()
+	defer agent.scoreLock.Unlock()
+	if _, ok := agent.scores[key]; !ok {
+		return 0
+	}
+	return agent.score(key, time.Now()).score
+}
+
+// misbehave records the error closing the connection, and penalizes the peer
+// for it.
+func (p *TCPPeer) misbehave(err error) {
+	select {
+	case <-p.die:
+		return
+	default:
+	}
+
+	p.recordError(err)
+	if penalty := gossipPenalty(err); penalty > 0 {
+		p.agent.penalize(p, penalty)
+	}
+}
+
+// gossipPenalty returns the penalty for an error closing the connection
+// while processing frames.
+func gossipPenalty(err error) float64 {
+	switch err {
+	case ErrPeerBanned, ErrGossipVersion, ErrProtocolVersion, ErrChainID:
+		// incompatible peers are not misbehaving
+		return 0
+	case ErrKeyNotOnCurve, ErrPeerAuthenticatedFailed, ErrPeerIdentityRejected:
+		return PenaltyAuthFailed
+	default:
+		return PenaltyMalformed
+	}
+}
+
+// messagePenalty returns the penalty for a consensus message rejected,
+// messages rejected for other reasons are not the sender's fault.
+func messagePenalty(err error) float64 {
+	if errors.Is(err, bdls.ErrMessageSignature) || errors.Is(err, bdls.ErrMessageUnknownParticipant) {
+		return PenaltyInvalidMessage
+	}
+	return 0
+}
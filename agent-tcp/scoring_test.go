@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// waitClosed waits for the connection to be closed
+func waitClosed(t *testing.T, p *TCPPeer) {
+	select {
+	case <-p.die:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection has not been closed")
+	}
+}
+
+// closedByRemote checks whether the remote closes the connection in a short time
+func closedByRemote(conn net.Conn) bool {
+	// discard handshakes from remote
+	conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	_, err := io.Copy(io.Discard, conn)
+	if err, ok := err.(net.Error); ok && err.Timeout() {
+		return false
+	}
+	return true
+}
+
+func TestUnknownCommand(t *testing.T) {
+	agents := createLineAgents(t, 2)
+	defer agents[0].Close()
+	defer agents[1].Close()
+	p0 := waitEstablished(t, agents[0], 1)[0]
+	p1 := waitEstablished(t, agents[1], 1)[0]
+
+	// an unknown command disconnects the peer instead of panicking
+	frame, err := proto.Marshal(&Gossip{Command: CommandType(99)})
+	assert.Nil(t, err)
+	p0.Lock()
+	p0.queue.push(queuedFrame{frame: frame}, priorityAgent)
+	p0.notifyAgentMessage()
+	p0.Unlock()
+
+	waitClosed(t, p1)
+	stats := p1.Stats()
+	assert.Equal(t, ErrUnknownCommand.Error(), stats.LastError)
+	assert.InDelta(t, PenaltyMalformed, stats.Score, 1)
+}
+
+func TestPeerBan(t *testing.T) {
+	agents := createAgents(t, 1)
+	defer agents[0].Close()
+	agents[0].SetBanPolicy(2*PenaltyMalformed-1, 500*time.Millisecond)
+	l := serve(t, agents[0])
+	defer l.Close()
+
+	// zero length frames are malformed
+	misbehave := func() bool {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		assert.Nil(t, err)
+		defer conn.Close()
+		conn.Write(make([]byte, MessageLength))
+		return closedByRemote(conn)
+	}
+
+	assert.True(t, misbehave())
+	assert.True(t, misbehave())
+
+	// the address has been banned
+	conn, err := net.Dial("tcp", l.Addr().String())
+	assert.Nil(t, err)
+	assert.True(t, closedByRemote(conn))
+	conn.Close()
+
+	// the ban expires
+	<-time.After(time.Second)
+	conn, err = net.Dial("tcp", l.Addr().String())
+	assert.Nil(t, err)
+	assert.False(t, closedByRemote(conn))
+	conn.Close()
+}
+
+func TestInvalidMessageBan(t *testing.T) {
+	agents := createLineAgents(t, 2)
+	defer agents[0].Close()
+	defer agents[1].Close()
+	agents[0].SetBanPolicy(3*PenaltyInvalidMessage-1, time.Minute)
+	p := waitEstablished(t, agents[0], 1)[0]
+
+	// messages from a non-participant
+	privateKey, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	m := bdls.Message{Type: bdls.MessageType_RoundChange, Height: 1}
+	sp := new(bdls.SignedProto)
+	sp.Sign(&m, privateKey)
+	out, err := proto.Marshal(sp)
+	assert.Nil(t, err)
+
+	for i := 0; i < 3; i++ {
+		agents[0].handleConsensusMessage(out, p)
+	}
+	waitClosed(t, p)
+
+	// the identity has been banned, and cannot reconnect
+	p1, _ := connectPipe(t, agents[0], agents[1])
+	waitClosed(t, p1)
+	assert.Equal(t, ErrPeerBanned.Error(), p1.Stats().LastError)
+}
+
+func TestScorePruning(t *testing.T) {
+	agents := createAgents(t, 1)
+	defer agents[0].Close()
+	agent := agents[0]
+
+	now := time.Now()
+	decayed := scoreKey{ip: "10.0.0.1"}
+	scored := scoreKey{ip: "10.0.0.2"}
+	banned := scoreKey{ip: "10.0.0.3"}
+
+	agent.scoreLock.Lock()
+	defer agent.scoreLock.Unlock()
+	agent.score(decayed, now).score = ScoreDecayPerMinute
+	agent.score(scored, now).score = 10 * ScoreDecayPerMinute
+	agent.score(banned, now).bannedUntil = now.Add(time.Hour)
+
+	// scores are pruned at most once per interval
+	later := now.Add(ScorePruneInterval / 2)
+	agent.pruneScores(later)
+	assert.Equal(t, 3, len(agent.scores))
+
+	later = agent.scoresPruned.Add(ScorePruneInterval)
+	agent.pruneScores(later)
+	assert.Equal(t, 2, len(agent.scores))
+	assert.NotContains(t, agent.scores, decayed)
+	assert.Contains(t, agent.scores, scored)
+	assert.Contains(t, agent.scores, banned)
+
+	// bans expired
+	later = later.Add(time.Hour)
+	agent.pruneScores(later)
+	assert.Equal(t, 0, len(agent.scores))
+}
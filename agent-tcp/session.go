@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/BDLS-bft/bdls/crypto/blake2b"
+)
+
+const (
+	// SessionKeyPrefix is the domain separator for deriving session keys
+	SessionKeyPrefix = "BDLS_SESSION_KEY"
+
+	// secretSize defines byte size of an encoded ECDH secret
+	secretSize = 32
+)
+
+// session encrypts and authenticates frames after both peers have
+// authenticated their public keys, a session has a key and a counter nonce
+// for each direction.
+type session struct {
+	send      cipher.AEAD
+	recv      cipher.AEAD
+	sendNonce uint64
+	recvNonce uint64
+}
+
+// newSession derives session keys from the secrets of both authentication
+// procedures and the KeyAuthInit messages sent by both sides, localSecret is
+// the one to authenticate my public key, and peerSecret is the one to
+// authenticate the peer's. Keys are derived as:
+// send key = blake2b(SessionKeyPrefix + localSecret + peerSecret + blake2b(localHello) + blake2b(peerHello))
+// recv key = blake2b(SessionKeyPrefix + peerSecret + localSecret + blake2b(peerHello) + blake2b(localHello))
+//
+// as my local secret is the peer's peer secret, my send key is the peer's
+// recv key, and vice versa. The hellos are sent in plaintext before the key
+// exchange, if any of them has been tampered in transit, both sides derive
+// different keys and no frame can be opened in session.
+func newSession(localSecret *big.Int, peerSecret *big.Int, localHello []byte, peerHello []byte) (*session, error) {
+	local := localSecret.FillBytes(make([]byte, secretSize))
+	peer := peerSecret.FillBytes(make([]byte, secretSize))
+	localHash := blake2b.Sum256(localHello)
+	peerHash := blake2b.Sum256(peerHello)
+
+	send, err := newAEAD(local, peer, localHash[:], peerHash[:])
+	if err != nil {
+		return nil, err
+	}
+
+	recv, err := newAEAD(peer, local, peerHash[:], localHash[:])
+	if err != nil {
+		return nil, err
+	}
+	return &session{send: send, recv: recv}, nil
+}
+
+// newAEAD creates AES-256-GCM with the key derived from secrets and hello hashes
+func newAEAD(first []byte, second []byte, firstHello []byte, secondHello []byte) (cipher.AEAD, error) {
+	material := make([]byte, 0, len(SessionKeyPrefix)+len(first)+len(second)+len(firstHello)+len(secondHello))
+	material = append(material, SessionKeyPrefix...)
+	material = append(material, first...)
+	material = append(material, second...)
+	material = append(material, firstHello...)
+	material = append(material, secondHello...)
+	key := blake2b.Sum256(material)
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// nonce encodes a counter as a GCM nonce
+func nonce(aead cipher.AEAD, counter uint64) []byte {
+	n := make([]byte, aead.NonceSize())
+	binary.LittleEndian.PutUint64(n, counter)
+	return n
+}
+
+// seal encrypts and authenticates a frame with the next send nonce
+func (s *session) seal(plaintext []byte) []byte {
+	out := s.send.Seal(nil, nonce(s.send, s.sendNonce), plaintext, nil)
+	s.sendNonce++
+	return out
+}
+
+// open decrypts and authenticates a frame with the next recv nonce, frames
+// MUST be opened in the order they were sealed.
+func (s *session) open(ciphertext []byte) ([]byte, error) {
+	out, err := s.recv.Open(nil, nonce(s.recv, s.recvNonce), ciphertext, nil)
+	if err != nil {
+		return nil, ErrFrameAuthentication
+	}
+	s.recvNonce++
+	return out, nil
+}
@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+	proto "github.com/gogo/protobuf/proto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession(t *testing.T) {
+	key1, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	key2, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	ephemeral1, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	ephemeral2, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+
+	hello1, hello2 := []byte("hello1"), []byte("hello2")
+
+	// key1 is authenticated with ephemeral2, and key2 with ephemeral1
+	s1, err := newSession(ECDH(&ephemeral2.PublicKey, key1), ECDH(&key2.PublicKey, ephemeral1), hello1, hello2)
+	assert.Nil(t, err)
+	s2, err := newSession(ECDH(&ephemeral1.PublicKey, key2), ECDH(&key1.PublicKey, ephemeral2), hello2, hello1)
+	assert.Nil(t, err)
+
+	for i := 0; i < 10; i++ {
+		out, err := s2.open(s1.seal([]byte("hello")))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("hello"), out)
+
+		out, err = s1.open(s2.seal([]byte("world")))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("world"), out)
+	}
+
+	// tampered
+	sealed := s1.seal([]byte("hello"))
+	sealed[0] ^= 0xff
+	_, err = s2.open(sealed)
+	assert.Equal(t, ErrFrameAuthentication, err)
+
+	// replayed or reordered frames cannot be opened
+	s1, _ = newSession(ECDH(&ephemeral2.PublicKey, key1), ECDH(&key2.PublicKey, ephemeral1), hello1, hello2)
+	s2, _ = newSession(ECDH(&ephemeral1.PublicKey, key2), ECDH(&key1.PublicKey, ephemeral2), hello2, hello1)
+	first := s1.seal([]byte("first"))
+	second := s1.seal([]byte("second"))
+	_, err = s2.open(second)
+	assert.Equal(t, ErrFrameAuthentication, err)
+	_, err = s2.open(first)
+	assert.Nil(t, err)
+	_, err = s2.open(first)
+	assert.Equal(t, ErrFrameAuthentication, err)
+
+	// keys are different in each direction
+	_, err = s1.open(s1.seal([]byte("reflected")))
+	assert.Equal(t, ErrFrameAuthentication, err)
+
+	// a hello tampered in transit derives different keys
+	s1, _ = newSession(ECDH(&ephemeral2.PublicKey, key1), ECDH(&key2.PublicKey, ephemeral1), hello1, hello2)
+	s2, _ = newSession(ECDH(&ephemeral1.PublicKey, key2), ECDH(&key1.PublicKey, ephemeral2), hello2, []byte("tampered"))
+	_, err = s2.open(s1.seal([]byte("hello")))
+	assert.Equal(t, ErrFrameAuthentication, err)
+	_, err = s1.open(s2.seal([]byte("world")))
+	assert.Equal(t, ErrFrameAuthentication, err)
+}
+
+func TestPlaintextFrameRejected(t *testing.T) {
+	agents := createLineAgents(t, 1)
+	defer agents[0].Close()
+
+	c1, c2 := net.Pipe()
+	p := NewTCPPeer(c1, agents[0])
+	assert.True(t, agents[0].AddPeer(p))
+
+	// a plaintext consensus frame closes the connection
+	out, err := proto.Marshal(&Gossip{Command: CommandType_CONSENSUS, Message: []byte("consensus")})
+	assert.Nil(t, err)
+	frame := make([]byte, MessageLength+len(out))
+	binary.LittleEndian.PutUint32(frame, uint32(len(out)))
+	copy(frame[MessageLength:], out)
+	_, err = c2.Write(frame)
+	assert.Nil(t, err)
+
+	select {
+	case <-p.die:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection has not been closed")
+	}
+}
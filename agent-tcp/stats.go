@@ -0,0 +1,287 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+)
+
+// MaxLinkStats is the maximum number of remote identities and addresses to
+// keep link statistics for, statistics of disconnected links are evicted
+// beyond.
+const MaxLinkStats = 1024
+
+// LinkStats is the statistics of a remote identity, or of an IP address for
+// connections not authenticated, kept across reconnects to find flapping
+// links.
+type LinkStats struct {
+	Identity bdls.Identity // zero for links counted by IP address
+	Address  string        // the latest remote address
+
+	Connects       uint64 // connections added to the agent
+	Disconnects    uint64 // connections removed from the agent
+	LastConnect    time.Time
+	LastDisconnect time.Time
+
+	Errors        uint64 // errors closed the connections
+	LastError     string
+	LastErrorTime time.Time
+}
+
+// PeerStats is the traffic and health statistics of a connection
+type PeerStats struct {
+	Address  string        // remote address
+	Identity bdls.Identity // zero until the peer has authenticated
+	Outbound bool          // dialed by the agent
+
+	// handshake state
+	PeerAuth  string // authentication state of the peer's public key
+	LocalAuth string // authentication state of my public key
+	Session   bool   // session established
+	Height    uint64 // the latest height announced by the peer
+
+	// traffic in each direction, bytes include length prefixes
+	FramesSent     uint64
+	FramesReceived uint64
+	BytesSent      uint64
+	BytesReceived  uint64
+
+	// consensus messages by type
+	ConsensusSent     map[bdls.MessageType]uint64
+	ConsensusReceived map[bdls.MessageType]uint64
+
+	ConnectedAt time.Time
+	LastSeen    time.Time // the time last frame received
+	LastError   string    // the error closed the connection
+	Score       float64   // misbehavior score, banned at the threshold
+
+	Queue QueueStats
+	RTT   RTTStats
+}
+
+// Stats returns the statistics of all connected peers
+func (agent *TCPAgent) Stats() []PeerStats {
+	agent.Lock()
+	peers := make([]*TCPPeer, len(agent.peers))
+	copy(peers, agent.peers)
+	agent.Unlock()
+
+	stats := make([]PeerStats, 0, len(peers))
+	for _, p := range peers {
+		stats = append(stats, p.Stats())
+	}
+	return stats
+}
+
+// LinkStats returns the statistics of all remote identities and IP addresses
+// ever connected, including disconnected ones, at most MaxLinkStats.
+func (agent *TCPAgent) LinkStats() []LinkStats {
+	agent.linkLock.Lock()
+	defer agent.linkLock.Unlock()
+
+	stats := make([]LinkStats, 0, len(agent.links))
+	for _, link := range agent.links {
+		stats = append(stats, *link)
+	}
+	return stats
+}
+
+// link returns the link statistics of the key, statistics of an arbitrary
+// disconnected link is evicted if there are MaxLinkStats already. The link
+// lock must be held.
+func (agent *TCPAgent) link(key scoreKey) *LinkStats {
+	if link, ok := agent.links[key]; ok {
+		return link
+	}
+
+	if len(agent.links) >= MaxLinkStats {
+		for k, link := range agent.links {
+			if link.Connects == link.Disconnects {
+				delete(agent.links, k)
+				break
+			}
+		}
+	}
+
+	link := &LinkStats{Identity: key.identity}
+	agent.links[key] = link
+	return link
+}
+
+// linkConnected counts a connection added to the agent, by identity if it
+// has authenticated, or by IP address.
+func (agent *TCPAgent) linkConnected(p *TCPPeer) {
+	address := p.RemoteAddr().String()
+
+	p.Lock()
+	defer p.Unlock()
+	key := addressKey(address)
+	if p.peerAuthStatus == peerAuthenticated {
+		key = scoreKey{identity: bdls.DefaultPubKeyToIdentity(p.peerPublicKey)}
+	}
+	p.linkKey = key
+	p.linked = true
+
+	agent.linkLock.Lock()
+	defer agent.linkLock.Unlock()
+	link := agent.link(key)
+	link.Address = address
+	link.Connects++
+	link.LastConnect = time.Now()
+}
+
+// linkAuthenticated moves the connection counted by IP address to the
+// authenticated identity, the peer's lock must be held.
+func (agent *TCPAgent) linkAuthenticated(p *TCPPeer) {
+	if !p.linked || p.linkKey.ip == "" {
+		return
+	}
+
+	key := scoreKey{identity: bdls.DefaultPubKeyToIdentity(p.peerPublicKey)}
+	agent.linkLock.Lock()
+	defer agent.linkLock.Unlock()
+	if from, ok := agent.links[p.linkKey]; ok {
+		from.Connects--
+		if from.Connects == 0 && from.Disconnects == 0 && from.Errors == 0 {
+			delete(agent.links, p.linkKey)
+		}
+	}
+
+	to := agent.link(key)
+	to.Address = p.RemoteAddr().String()
+	to.Connects++
+	to.LastConnect = p.stats.ConnectedAt
+	p.linkKey = key
+}
+
+// linkDisconnected counts a connection removed from the agent
+func (agent *TCPAgent) linkDisconnected(p *TCPPeer) {
+	p.Lock()
+	defer p.Unlock()
+	if !p.linked {
+		return
+	}
+
+	agent.linkLock.Lock()
+	defer agent.linkLock.Unlock()
+	link := agent.link(p.linkKey)
+	link.Disconnects++
+	link.LastDisconnect = time.Now()
+}
+
+// Stats returns the statistics of this connection
+func (p *TCPPeer) Stats() PeerStats {
+	rtt := p.RTTStats()
+	score := p.agent.peerScore(p)
+
+	p.Lock()
+	defer p.Unlock()
+	stats := p.stats
+	stats.Address = p.RemoteAddr().String()
+	stats.Outbound = p.outbound()
+	if p.peerAuthStatus == peerAuthenticated {
+		stats.Identity = bdls.DefaultPubKeyToIdentity(p.peerPublicKey)
+	}
+	stats.PeerAuth = peerAuthString(p.peerAuthStatus)
+	stats.LocalAuth = localAuthString(p.localAuthState)
+	stats.Session = p.session != nil
+	stats.Height = p.peerHeight
+
+	stats.ConsensusSent = make(map[bdls.MessageType]uint64)
+	for t, n := range p.stats.ConsensusSent {
+		stats.ConsensusSent[t] = n
+	}
+	stats.ConsensusReceived = make(map[bdls.MessageType]uint64)
+	for t, n := range p.stats.ConsensusReceived {
+		stats.ConsensusReceived[t] = n
+	}
+
+	stats.Queue = p.queue.stats
+	stats.RTT = rtt
+	stats.Score = score
+	return stats
+}
+
+// countSent counts a frame written, the lock must be held
+func (p *TCPPeer) countSent(size int) {
+	p.stats.FramesSent++
+	p.stats.BytesSent += uint64(MessageLength + size)
+}
+
+// countReceived counts a frame read, the lock must be held
+func (p *TCPPeer) countReceived(size int, now time.Time) {
+	p.stats.FramesReceived++
+	p.stats.BytesReceived += uint64(MessageLength + size)
+	p.stats.LastSeen = now
+}
+
+// countConsensusSent counts a consensus message sent
+func (p *TCPPeer) countConsensusSent(t bdls.MessageType) {
+	p.Lock()
+	defer p.Unlock()
+	p.stats.ConsensusSent[t]++
+}
+
+// countConsensusReceived counts a consensus message received
+func (p *TCPPeer) countConsensusReceived(bts []byte) {
+	m, ok := decodeConsensusMessage(bts)
+	if !ok {
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	p.stats.ConsensusReceived[m.Type]++
+}
+
+// recordError records the error closing the connection, and counts it in
+// the link statistics, errors after the connection has been closed are
+// ignored.
+func (p *TCPPeer) recordError(err error) {
+	select {
+	case <-p.die:
+		return
+	default:
+	}
+
+	p.Lock()
+	defer p.Unlock()
+	p.stats.LastError = err.Error()
+	if !p.linked {
+		return
+	}
+
+	agent := p.agent
+	agent.linkLock.Lock()
+	defer agent.linkLock.Unlock()
+	link := agent.link(p.linkKey)
+	link.Errors++
+	link.LastError = p.stats.LastError
+	link.LastErrorTime = time.Now()
+}
+
+// peerAuthString describes the authentication state of the peer's public key
+func peerAuthString(state authenticationState) string {
+	switch state {
+	case peerNotAuthenticated:
+		return "not authenticated"
+	case peerAuthkeyReceived:
+		return "challenge sent"
+	case peerAuthenticated:
+		return "authenticated"
+	default:
+		return "failed"
+	}
+}
+
+// localAuthString describes the authentication state of my public key
+func localAuthString(state authenticationState) string {
+	switch state {
+	case localNotAuthenticated:
+		return "not authenticated"
+	case localAuthKeySent:
+		return "key sent"
+	default:
+		return "authenticated"
+	}
+}
@@ -0,0 +1,135 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeerStats(t *testing.T) {
+	agents := createAgents(t, 2)
+	for _, agent := range agents {
+		defer agent.Close()
+	}
+	p1, p2 := connectPipe(t, agents[0], agents[1])
+	waitEstablished(t, agents[0], 1)
+	waitEstablished(t, agents[1], 1)
+
+	m := bdls.Message{Type: bdls.MessageType_RoundChange, Height: 1}
+	sp := new(bdls.SignedProto)
+	sp.Sign(&m, agents[0].privateKey)
+	out, err := proto.Marshal(sp)
+	assert.Nil(t, err)
+	assert.Nil(t, p1.Send(out))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for p2.Stats().ConsensusReceived[bdls.MessageType_RoundChange] == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("consensus message has not been received in time")
+		}
+		<-time.After(10 * time.Millisecond)
+	}
+
+	stats := agents[0].Stats()
+	assert.Equal(t, 1, len(stats))
+	s := stats[0]
+	assert.Equal(t, bdls.DefaultPubKeyToIdentity(&agents[1].privateKey.PublicKey), s.Identity)
+	assert.Equal(t, "authenticated", s.PeerAuth)
+	assert.Equal(t, "authenticated", s.LocalAuth)
+	assert.True(t, s.Session)
+	assert.False(t, s.Outbound)
+	assert.True(t, s.FramesSent >= 4)
+	assert.True(t, s.FramesReceived >= 3)
+	assert.True(t, s.BytesSent > s.FramesSent*MessageLength)
+	assert.True(t, s.BytesReceived > s.FramesReceived*MessageLength)
+	assert.Equal(t, uint64(1), s.ConsensusSent[bdls.MessageType_RoundChange])
+	assert.False(t, s.LastSeen.Before(s.ConnectedAt))
+	assert.Equal(t, 0, s.Queue.Messages)
+
+	received := p2.Stats()
+	assert.Equal(t, s.FramesSent, received.FramesReceived)
+	assert.Equal(t, s.BytesSent, received.BytesReceived)
+
+	// the error closing the connection is recorded
+	p2.conn.Close()
+	<-p1.die
+	s = p1.Stats()
+	assert.Equal(t, io.EOF.Error(), s.LastError)
+}
+
+func TestLinkStats(t *testing.T) {
+	agents := createAgents(t, 2)
+	for _, agent := range agents {
+		defer agent.Close()
+	}
+	identity := bdls.DefaultPubKeyToIdentity(&agents[1].privateKey.PublicKey)
+
+	// links are counted by the identity across reconnects
+	for i := 1; i <= 3; i++ {
+		p1, p2 := connectPipe(t, agents[0], agents[1])
+		waitEstablished(t, agents[0], 1)
+		waitEstablished(t, agents[1], 1)
+
+		p2.conn.Close()
+		<-p1.die
+		deadline := time.Now().Add(5 * time.Second)
+		for len(agents[0].Stats()) != 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("peer has not been removed in time")
+			}
+			<-time.After(10 * time.Millisecond)
+		}
+
+		links := agents[0].LinkStats()
+		assert.Equal(t, 1, len(links))
+		link := links[0]
+		assert.Equal(t, identity, link.Identity)
+		assert.Equal(t, uint64(i), link.Connects)
+		assert.Equal(t, uint64(i), link.Disconnects)
+		assert.Equal(t, uint64(i), link.Errors)
+		assert.Equal(t, io.EOF.Error(), link.LastError)
+		assert.False(t, link.LastErrorTime.Before(link.LastConnect))
+		assert.False(t, link.LastDisconnect.Before(link.LastConnect))
+	}
+
+	// connections not authenticated are counted by address
+	c1, c2 := net.Pipe()
+	p := NewTCPPeer(c1, agents[0])
+	assert.True(t, agents[0].AddPeer(p))
+	c2.Close()
+	<-p.die
+
+	var found bool
+	for _, link := range agents[0].LinkStats() {
+		if link.Identity == (bdls.Identity{}) {
+			found = true
+			assert.Equal(t, p.RemoteAddr().String(), link.Address)
+			assert.Equal(t, uint64(1), link.Connects)
+			assert.Equal(t, uint64(1), link.Errors)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestLinkStatsEviction(t *testing.T) {
+	agent := createAgents(t, 1)[0]
+	defer agent.Close()
+
+	agent.linkLock.Lock()
+	defer agent.linkLock.Unlock()
+
+	// links with live connections are never evicted
+	live := agent.link(scoreKey{ip: "live"})
+	live.Connects = 1
+	for i := 0; i < 2*MaxLinkStats; i++ {
+		agent.link(scoreKey{ip: fmt.Sprint(i)})
+	}
+	assert.Equal(t, MaxLinkStats, len(agent.links))
+	assert.Equal(t, live, agent.links[scoreKey{ip: "live"}])
+}
@@ -36,8 +36,43 @@ const (
 
 	// challengeSize
 	challengeSize = 1024
+
+	// MaxProposalSize is the maximum size of a gossiped proposal(8MB)
+	MaxProposalSize = 8 * 1024 * 1024
+
+	// MaxProposalsPerHeight is the maximum number of proposals received
+	// from a peer at a height, proposals beyond will be dropped
+	MaxProposalsPerHeight = 256
+
+	// MaxProposalBytesPerHeight is the maximum total size of proposals
+	// received from a peer at a height(64MB), proposals beyond will be dropped
+	MaxProposalBytesPerHeight = 64 * 1024 * 1024
+
+	// DefaultAuthTimeout is the default deadline for a connection to
+	// complete public key authentication in both directions
+	DefaultAuthTimeout = 10 * time.Second
 )
 
+// IdentityPolicy decides whether a peer with the public key is allowed to connect
+type IdentityPolicy func(pubkey *ecdsa.PublicKey) bool
+
+// ParticipantsPolicy allows only participants of the consensus to connect
+func ParticipantsPolicy(consensus *bdls.Consensus) IdentityPolicy {
+	return consensus.IsParticipant
+}
+
+// AllowlistPolicy allows only the identities to connect, identities are
+// derived with bdls.DefaultPubKeyToIdentity.
+func AllowlistPolicy(identities []bdls.Identity) IdentityPolicy {
+	allowed := make(map[bdls.Identity]bool)
+	for _, id := range identities {
+		allowed[id] = true
+	}
+	return func(pubkey *ecdsa.PublicKey) bool {
+		return allowed[bdls.DefaultPubKeyToIdentity(pubkey)]
+	}
+}
+
 // authenticationState is the authentication status for both peer
 type authenticationState byte
 
@@ -68,9 +103,56 @@ type TCPAgent struct {
 	consensus           *bdls.Consensus   // the consensus core
 	privateKey          *ecdsa.PrivateKey // a private key to sign messages
 	peers               []*TCPPeer        // connected peers
-	consensusMessages   [][]byte          // all consensus message awaiting to be processed
+	consensusMessages   []consensusMessage // all consensus message awaiting to be processed
 	chConsensusMessages chan struct{}     // notification of new consensus message
 
+	// gossiped proposals at current height, proposals are seen once they
+	// have been validated, and limited by the peers they are received from
+	proposals           map[bdls.StateHash]bool
+	proposalsValidating map[bdls.StateHash]bool
+	proposalQuotas      map[scoreKey]*proposalQuota
+	proposalsHeight     uint64
+
+	// peer admission and queue limits, guarded by policyLock as it's read
+	// with peer's lock held
+	identityPolicy IdentityPolicy
+	authTimeout    time.Duration
+	queueMessages  int
+	queueBytes     int
+	compression    int // compression threshold, 0 to disable
+	pingInterval   time.Duration
+	policyLock     sync.Mutex
+
+	// percentile of RTTs to set consensus latency, 0 to disable
+	latencyPercentile float64
+	latency           time.Duration // latency set from RTTs
+
+	// address book of peers to keep connections to
+	addresses  map[string]*addressEntry
+	sessionSeq uint64 // sequence of established sessions
+
+	// signed peer records for discovery, and the file to persist address book
+	records         map[bdls.Identity]*PeerRecord
+	addressBookPath string
+	addressBookLock sync.Mutex // file writing lock
+
+	// misbehavior scores and bans of peers, guarded by scoreLock
+	scores       map[scoreKey]*peerScore
+	scoresPruned time.Time // the last time scores were pruned
+	banThreshold float64
+	banDuration  time.Duration
+	scoreLock    sync.Mutex
+
+	// statistics of remote identities and addresses across reconnects,
+	// guarded by linkLock
+	links    map[scoreKey]*LinkStats
+	linkLock sync.Mutex
+
+	// scheduled consensus update
+	updateScheduled bool
+	nextUpdate      time.Time
+	updateSeq       uint64
+
 	die        chan struct{} // tcp agent closing
 	dieOnce    sync.Once
 	sync.Mutex // fields lock
@@ -83,12 +165,30 @@ func NewTCPAgent(consensus *bdls.Consensus, privateKey *ecdsa.PrivateKey) *TCPAg
 	agent.privateKey = privateKey
 	agent.die = make(chan struct{})
 	agent.chConsensusMessages = make(chan struct{}, 1)
+	agent.proposals = make(map[bdls.StateHash]bool)
+	agent.proposalsValidating = make(map[bdls.StateHash]bool)
+	agent.proposalQuotas = make(map[scoreKey]*proposalQuota)
+	agent.authTimeout = DefaultAuthTimeout
+	agent.queueMessages = DefaultQueueMessages
+	agent.queueBytes = DefaultQueueBytes
+	agent.pingInterval = DefaultPingInterval
+	agent.addresses = make(map[string]*addressEntry)
+	agent.records = make(map[bdls.Identity]*PeerRecord)
+	agent.scores = make(map[scoreKey]*peerScore)
+	agent.banThreshold = DefaultBanThreshold
+	agent.banDuration = DefaultBanDuration
+	agent.links = make(map[scoreKey]*LinkStats)
 	go agent.inputConsensusMessage()
 	return agent
 }
 
-// AddPeer adds a peer to this agent
+// AddPeer adds a peer to this agent, peers from banned addresses will not be
+// added.
 func (agent *TCPAgent) AddPeer(p *TCPPeer) bool {
+	if agent.banned(peerScoreKey(p)) {
+		return false
+	}
+
 	agent.Lock()
 	defer agent.Unlock()
 
@@ -97,10 +197,69 @@ func (agent *TCPAgent) AddPeer(p *TCPPeer) bool {
 		return false
 	default:
 		agent.peers = append(agent.peers, p)
+		agent.linkConnected(p)
 		return agent.consensus.Join(p)
 	}
 }
 
+// SetIdentityPolicy sets the policy to admit peers by their public keys,
+// nil allows any peer. Authenticated peers not allowed by the new policy
+// will be disconnected.
+func (agent *TCPAgent) SetIdentityPolicy(policy IdentityPolicy) {
+	agent.policyLock.Lock()
+	agent.identityPolicy = policy
+	agent.policyLock.Unlock()
+
+	agent.Lock()
+	peers := make([]*TCPPeer, len(agent.peers))
+	copy(peers, agent.peers)
+	agent.Unlock()
+
+	for _, p := range peers {
+		if pubkey := p.GetPublicKey(); pubkey != nil && !agent.allowed(pubkey) {
+			p.Close()
+		}
+	}
+}
+
+// SetAuthTimeout sets the deadline for new connections to complete public
+// key authentication in both directions, 0 to disable.
+func (agent *TCPAgent) SetAuthTimeout(timeout time.Duration) {
+	agent.policyLock.Lock()
+	defer agent.policyLock.Unlock()
+	agent.authTimeout = timeout
+}
+
+// SetQueueLimits sets the maximum number of messages and bytes queued to
+// send to each peer, for new connections.
+func (agent *TCPAgent) SetQueueLimits(maxMessages int, maxBytes int) {
+	agent.policyLock.Lock()
+	defer agent.policyLock.Unlock()
+	agent.queueMessages = maxMessages
+	agent.queueBytes = maxBytes
+}
+
+// SetCompression enables DEFLATE compression for new connections, frames
+// not smaller than threshold will be compressed if the peer supports, 0 to
+// disable.
+func (agent *TCPAgent) SetCompression(threshold int) {
+	agent.policyLock.Lock()
+	defer agent.policyLock.Unlock()
+	agent.compression = threshold
+}
+
+// EnableCompression enables DEFLATE compression for new connections with
+// DefaultCompressionThreshold.
+func (agent *TCPAgent) EnableCompression() { agent.SetCompression(DefaultCompressionThreshold) }
+
+// allowed checks the public key against identity policy
+func (agent *TCPAgent) allowed(pubkey *ecdsa.PublicKey) bool {
+	agent.policyLock.Lock()
+	policy := agent.identityPolicy
+	agent.policyLock.Unlock()
+	return policy == nil || policy(pubkey)
+}
+
 // RemovePeer removes a TCPPeer from this agent
 func (agent *TCPAgent) RemovePeer(p *TCPPeer) bool {
 	agent.Lock()
@@ -111,6 +270,7 @@ func (agent *TCPAgent) RemovePeer(p *TCPPeer) bool {
 		if agent.peers[k].RemoteAddr().String() == peerAddress {
 			copy(agent.peers[k:], agent.peers[k+1:])
 			agent.peers = agent.peers[:len(agent.peers)-1]
+			agent.linkDisconnected(p)
 			return agent.consensus.Leave(p.RemoteAddr())
 		}
 	}
@@ -131,25 +291,187 @@ func (agent *TCPAgent) Close() {
 	})
 }
 
-// Update is the consensus updater
+// Update is the consensus updater, it schedules itself at the next deadline
+// of consensus.
 func (agent *TCPAgent) Update() {
 	agent.Lock()
 	defer agent.Unlock()
+	agent.update()
+}
 
+// update calls consensus update and reschedules, the lock must be held.
+func (agent *TCPAgent) update() {
 	select {
 	case <-agent.die:
 	default:
 		// call consensus update
 		agent.consensus.Update(time.Now())
-		timer.SystemTimedSched.Put(agent.Update, time.Now().Add(20*time.Millisecond))
+		agent.scheduleUpdate()
 	}
 }
 
-// Propose a state, awaiting to be finalized at next height.
+// scheduleUpdate schedules an update at the next deadline of consensus, if
+// it's earlier than the one scheduled, the lock must be held.
+func (agent *TCPAgent) scheduleUpdate() {
+	deadline := agent.consensus.NextDeadline()
+	if agent.updateScheduled && !deadline.Before(agent.nextUpdate) {
+		return
+	}
+
+	agent.updateSeq++
+	agent.updateScheduled = true
+	agent.nextUpdate = deadline
+
+	seq := agent.updateSeq
+	timer.SystemTimedSched.Put(func() {
+		agent.Lock()
+		defer agent.Unlock()
+		// superseded by an earlier update
+		if !agent.updateScheduled || seq != agent.updateSeq {
+			return
+		}
+		agent.updateScheduled = false
+		agent.update()
+	}, deadline)
+}
+
+// Propose a state, awaiting to be finalized at next height, the state will
+// be gossiped to all authenticated peers.
 func (agent *TCPAgent) Propose(s bdls.State) {
 	agent.Lock()
 	defer agent.Unlock()
 	agent.consensus.Propose(s)
+	if len(s) <= MaxProposalSize && agent.markProposal(s) {
+		agent.gossipProposal(s, nil)
+	}
+}
+
+// proposalQuota is the proposals received from a peer at current height
+type proposalQuota struct {
+	count int
+	bytes int
+}
+
+// handleProposal will be called if TCPPeer received a proposal, valid
+// proposals will be proposed and relayed to other peers, and senders of
+// invalid proposals will be penalized.
+//
+// NOTE: StateValidate is called without holding the lock, it may be called
+// concurrently with consensus.
+func (agent *TCPAgent) handleProposal(s bdls.State, from *TCPPeer) error {
+	if len(s) > MaxProposalSize {
+		return ErrProposalSize
+	}
+
+	// seen, being validated, or too many proposals from the peer
+	agent.Lock()
+	height, ok := agent.admitProposal(s, from)
+	agent.Unlock()
+	if !ok {
+		return nil
+	}
+
+	// NOTE: the proposal may be for another height, an invalid proposal
+	// will be dropped with a small penalty.
+	valid := agent.consensus.ValidateState(s)
+
+	agent.Lock()
+	if agent.markValidatedProposal(s, height, valid) {
+		agent.consensus.Propose(s)
+		agent.gossipProposal(s, from)
+	}
+	agent.Unlock()
+
+	if !valid {
+		agent.penalize(from, PenaltyInvalidProposal)
+	}
+	return nil
+}
+
+// syncProposals forgets the proposals of previous heights, the lock must be
+// held.
+func (agent *TCPAgent) syncProposals() {
+	height, _, _ := agent.consensus.CurrentState()
+	if height != agent.proposalsHeight {
+		agent.proposals = make(map[bdls.StateHash]bool)
+		agent.proposalsValidating = make(map[bdls.StateHash]bool)
+		agent.proposalQuotas = make(map[scoreKey]*proposalQuota)
+		agent.proposalsHeight = height
+	}
+}
+
+// admitProposal admits a proposal from the peer to be validated, returns
+// the current height, and false if it has been seen or is being validated,
+// or the proposals received from the peer at current height have exceeded
+// MaxProposalsPerHeight or MaxProposalBytesPerHeight, the lock must be held.
+func (agent *TCPAgent) admitProposal(s bdls.State, from *TCPPeer) (uint64, bool) {
+	agent.syncProposals()
+	hash := agent.consensus.StateHash(s)
+	if agent.proposals[hash] || agent.proposalsValidating[hash] {
+		return 0, false
+	}
+
+	key := peerScoreKey(from)
+	quota, ok := agent.proposalQuotas[key]
+	if !ok {
+		quota = new(proposalQuota)
+		agent.proposalQuotas[key] = quota
+	}
+
+	if quota.count >= MaxProposalsPerHeight || quota.bytes+len(s) > MaxProposalBytesPerHeight {
+		return 0, false
+	}
+	quota.count++
+	quota.bytes += len(s)
+
+	agent.proposalsValidating[hash] = true
+	return agent.proposalsHeight, true
+}
+
+// markValidatedProposal marks an admitted proposal as seen if it's valid,
+// returns false if it's invalid, or has been seen, or the height has changed
+// since admitted, the lock must be held.
+func (agent *TCPAgent) markValidatedProposal(s bdls.State, height uint64, valid bool) bool {
+	agent.syncProposals()
+	if height != agent.proposalsHeight {
+		return false
+	}
+
+	hash := agent.consensus.StateHash(s)
+	delete(agent.proposalsValidating, hash)
+	if !valid || agent.proposals[hash] {
+		return false
+	}
+	agent.proposals[hash] = true
+	return true
+}
+
+// markProposal marks a local proposal as seen at current height, returns
+// false if it has been seen, the lock must be held.
+func (agent *TCPAgent) markProposal(s bdls.State) bool {
+	agent.syncProposals()
+	hash := agent.consensus.StateHash(s)
+	if agent.proposals[hash] {
+		return false
+	}
+	agent.proposals[hash] = true
+	return true
+}
+
+// gossipProposal sends a proposal to all authenticated peers except the one
+// it's from, the lock must be held.
+func (agent *TCPAgent) gossipProposal(s bdls.State, from *TCPPeer) {
+	g := Gossip{Command: CommandType_PROPOSAL, Message: s}
+	out, err := proto.Marshal(&g)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, p := range agent.peers {
+		if p != from && p.GetPublicKey() != nil {
+			p.sendAgentMessage(out)
+		}
+	}
 }
 
 // GetLatestState returns latest state
@@ -159,11 +481,65 @@ func (agent *TCPAgent) GetLatestState() (height uint64, round uint64, data bdls.
 	return agent.consensus.CurrentState()
 }
 
+// SubmitRequest submits an encoded client request to consensus, and forwards
+// it to all peers if it's accepted.
+func (agent *TCPAgent) SubmitRequest(bts []byte) error {
+	agent.Lock()
+	defer agent.Unlock()
+	return agent.submitRequest(bts, nil)
+}
+
+// handleClientRequest will be called if TCPPeer received a client request,
+// requests accepted will be relayed to other peers.
+func (agent *TCPAgent) handleClientRequest(bts []byte, from *TCPPeer) {
+	agent.Lock()
+	defer agent.Unlock()
+	// NOTE: relayed requests may arrive more than once, and duplicates
+	// are not relayed again.
+	_ = agent.submitRequest(bts, from)
+}
+
+// submitRequest submits the request and forwards it to peers except the
+// one it's from, the lock must be held.
+func (agent *TCPAgent) submitRequest(bts []byte, from *TCPPeer) error {
+	err := agent.consensus.SubmitRequest(bts, time.Now())
+	if err != nil {
+		return err
+	}
+
+	g := Gossip{Command: CommandType_CLIENT_REQUEST, Message: bts}
+	out, err := proto.Marshal(&g)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, p := range agent.peers {
+		if p != from {
+			p.sendAgentMessage(out)
+		}
+	}
+	return nil
+}
+
+// PendingRequests returns client requests awaiting to be included in a proposal
+func (agent *TCPAgent) PendingRequests() []*bdls.ClientRequest {
+	agent.Lock()
+	defer agent.Unlock()
+	return agent.consensus.PendingRequests()
+}
+
+// consensusMessage is a consensus message awaiting to be processed, with the
+// peer it's received from.
+type consensusMessage struct {
+	bts  []byte
+	from *TCPPeer
+}
+
 // handleConsensusMessage will be called if TCPPeer received a consensus message
-func (agent *TCPAgent) handleConsensusMessage(bts []byte) {
+func (agent *TCPAgent) handleConsensusMessage(bts []byte, from *TCPPeer) {
 	agent.Lock()
 	defer agent.Unlock()
-	agent.consensusMessages = append(agent.consensusMessages, bts)
+	agent.consensusMessages = append(agent.consensusMessages, consensusMessage{bts, from})
 	agent.notifyConsensus()
 }
 
@@ -183,10 +559,26 @@ func (agent *TCPAgent) inputConsensusMessage() {
 			msgs := agent.consensusMessages
 			agent.consensusMessages = nil
 
+			var rejected []consensusMessage
 			for _, msg := range msgs {
-				agent.consensus.ReceiveMessage(msg, time.Now())
+				err := agent.consensus.ReceiveMessage(msg.bts, time.Now())
+				if messagePenalty(err) > 0 {
+					rejected = append(rejected, msg)
+				}
+			}
+			// messages may change the deadline
+			if len(msgs) > 0 {
+				agent.scheduleUpdate()
 			}
 			agent.Unlock()
+
+			// penalize senders of invalid messages
+			for _, msg := range rejected {
+				agent.penalize(msg.from, PenaltyInvalidMessage)
+			}
+		case <-agent.consensus.ValidationNotify():
+			// new verdicts for parked messages
+			agent.Update()
 		case <-agent.die:
 			return
 		}
@@ -210,16 +602,54 @@ type TCPPeer struct {
 	// local authentication status
 	localAuthState authenticationState
 
+	// features announced in KeyAuthInit by both sides, and the threshold to
+	// compress frames
+	localFeatures        uint64
+	peerFeatures         uint64
+	compressionThreshold int
+
+	// the latest height announced by the peer in KeyAuthInit
+	peerHeight uint64
+
+	// timestamp of the ping awaiting pong, and recent RTTs
+	lastPing int64
+	rtts     []time.Duration
+	rttNext  int
+
+	// traffic and health counters
+	stats PeerStats
+	// the key of link statistics this connection is counted in, set once
+	// added to the agent, and moved to the identity on authentication
+	linkKey scoreKey
+	linked  bool
+
 	// the HMAC of the challenge text if peer has requested key authentication
 	hmac []byte
 
-	// message queues and their notifications
-	consensusMessages  [][]byte      // all pending outgoing consensus messages to this peer
+	// ECDH secrets of both authentication procedures, and KeyAuthInit
+	// messages sent by both sides, to derive session keys
+	localSecret *big.Int
+	peerSecret  *big.Int
+	localHello  []byte
+	peerHello   []byte
+	// the encrypted session, established after both public keys have been
+	// authenticated, frames other than handshakes are sent in session only.
+	session *session
+
+	// pending outgoing handshake messages, which are always sent in plaintext
+	handshakeMessages [][]byte
+
+	// the address dialed by agent, empty for incoming connections
+	dialAddress string
+	// sequence of the session, 0 if session has not been established
+	sessionSeq uint64
+	// the connection to the same identity which superseded this one
+	supersededBy *TCPPeer
+
+	// the bounded priority queue of outgoing consensus and agent messages
+	queue              *sendQueue
 	chConsensusMessage chan struct{} // notification on new consensus data
-
-	// agent messages
-	agentMessages  [][]byte      // all pending outgoing agent messages to this peer.
-	chAgentMessage chan struct{} // notification on new agent exchange messages
+	chAgentMessage     chan struct{} // notification on new agent exchange messages
 
 	// peer closing signal
 	die     chan struct{}
@@ -231,7 +661,24 @@ type TCPPeer struct {
 
 // NewTCPPeer creates a TCPPeer with protocol over this connection
 func NewTCPPeer(conn net.Conn, agent *TCPAgent) *TCPPeer {
+	return newTCPPeer(conn, agent, "")
+}
+
+// newTCPPeer creates a TCPPeer with the address dialed by the agent
+func newTCPPeer(conn net.Conn, agent *TCPAgent, dialAddress string) *TCPPeer {
 	p := new(TCPPeer)
+	p.dialAddress = dialAddress
+	p.stats.ConnectedAt = time.Now()
+	p.stats.ConsensusSent = make(map[bdls.MessageType]uint64)
+	p.stats.ConsensusReceived = make(map[bdls.MessageType]uint64)
+	agent.policyLock.Lock()
+	p.queue = newSendQueue(agent.queueMessages, agent.queueBytes)
+	if agent.compression > 0 {
+		p.localFeatures |= FeatureDeflate
+		p.compressionThreshold = agent.compression
+	}
+	pingInterval := agent.pingInterval
+	agent.policyLock.Unlock()
 	p.chConsensusMessage = make(chan struct{}, 1)
 	p.chAgentMessage = make(chan struct{}, 1)
 	p.conn = conn
@@ -240,6 +687,17 @@ func NewTCPPeer(conn net.Conn, agent *TCPAgent) *TCPPeer {
 	// we start readLoop & sendLoop for each connection
 	go p.readLoop()
 	go p.sendLoop()
+	if pingInterval > 0 {
+		go p.pingLoop(pingInterval)
+	}
+
+	// drop the connection if it's not authenticated in time
+	agent.policyLock.Lock()
+	timeout := agent.authTimeout
+	agent.policyLock.Unlock()
+	if timeout > 0 {
+		timer.SystemTimedSched.Put(p.checkAuthenticated, time.Now().Add(timeout))
+	}
 	return p
 }
 
@@ -263,15 +721,36 @@ func (p *TCPPeer) RemoteAddr() net.Addr {
 	return p.conn.RemoteAddr()
 }
 
-// Send implements PeerInterface, to send message to this peer
+// Send implements PeerInterface, to send message to this peer, messages are
+// queued by priority, ErrQueueFull will be returned if it's dropped.
 func (p *TCPPeer) Send(out []byte) error {
+	f, priority := newConsensusFrame(out)
 	p.Lock()
 	defer p.Unlock()
-	p.consensusMessages = append(p.consensusMessages, out)
+	if !p.queue.push(f, priority) {
+		return ErrQueueFull
+	}
 	p.notifyConsensusMessage()
 	return nil
 }
 
+// sendAgentMessage enqueues an encoded gossip message to this peer, agent
+// messages are of the lowest priority.
+func (p *TCPPeer) sendAgentMessage(out []byte) {
+	p.Lock()
+	defer p.Unlock()
+	if p.queue.push(queuedFrame{frame: out}, priorityAgent) {
+		p.notifyAgentMessage()
+	}
+}
+
+// QueueStats returns the statistics of outgoing queue
+func (p *TCPPeer) QueueStats() QueueStats {
+	p.Lock()
+	defer p.Unlock()
+	return p.queue.stats
+}
+
 // notifyConsensusMessage notifies goroutines there're messages pending to send
 func (p *TCPPeer) notifyConsensusMessage() {
 	select {
@@ -288,6 +767,43 @@ func (p *TCPPeer) notifyAgentMessage() {
 	}
 }
 
+// checkAuthenticated closes the connection if the session has not been
+// established
+func (p *TCPPeer) checkAuthenticated() {
+	p.Lock()
+	established := p.session != nil
+	p.Unlock()
+	if !established {
+		p.Close()
+	}
+}
+
+// sessionInfo returns the peer's public key and the session sequence, seq
+// is 0 if session has not been established.
+func (p *TCPPeer) sessionInfo() (pubkey *ecdsa.PublicKey, seq uint64) {
+	p.Lock()
+	defer p.Unlock()
+	return p.peerPublicKey, p.sessionSeq
+}
+
+// outbound returns true if the connection has been dialed by the agent
+func (p *TCPPeer) outbound() bool { return p.dialAddress != "" }
+
+// supersede closes this connection in favor of the duplicated connection
+func (p *TCPPeer) supersede(by *TCPPeer) {
+	p.Lock()
+	p.supersededBy = by
+	p.Unlock()
+	p.Close()
+}
+
+// superseded returns the connection which superseded this one, or nil
+func (p *TCPPeer) superseded() *TCPPeer {
+	p.Lock()
+	defer p.Unlock()
+	return p.supersededBy
+}
+
 // Close terminates connection to this peer
 func (p *TCPPeer) Close() {
 	p.dieOnce.Do(func() {
@@ -300,12 +816,17 @@ func (p *TCPPeer) Close() {
 // InitiatePublicKeyAuthentication will initate a procedure to convince
 // the other peer to trust my ownership of public key
 func (p *TCPPeer) InitiatePublicKeyAuthentication() error {
+	// the latest height is announced in hello
+	height, _, _ := p.agent.GetLatestState()
+
 	p.Lock()
 	defer p.Unlock()
 	if p.localAuthState == localNotAuthenticated {
 		auth := KeyAuthInit{}
 		auth.X = p.agent.privateKey.PublicKey.X.Bytes()
 		auth.Y = p.agent.privateKey.PublicKey.Y.Bytes()
+		auth.Features = p.localFeatures
+		p.agent.fillHello(&auth, height)
 
 		// proto marshal
 		bts, err := proto.Marshal(&auth)
@@ -313,6 +834,8 @@ func (p *TCPPeer) InitiatePublicKeyAuthentication() error {
 			panic(err)
 		}
 
+		p.localHello = bts
+
 		g := Gossip{Command: CommandType_KEY_AUTH_INIT, Message: bts}
 		// proto marshal
 		out, err := proto.Marshal(&g)
@@ -321,7 +844,7 @@ func (p *TCPPeer) InitiatePublicKeyAuthentication() error {
 		}
 
 		// enqueue
-		p.agentMessages = append(p.agentMessages, out)
+		p.handshakeMessages = append(p.handshakeMessages, out)
 		p.notifyAgentMessage()
 		p.localAuthState = localAuthKeySent
 		return nil
@@ -342,7 +865,7 @@ func (p *TCPPeer) handleGossip(msg *Gossip) error {
 			return err
 		}
 
-		err = p.handleKeyAuthInit(&m)
+		err = p.handleKeyAuthInit(&m, msg.Message)
 		if err != nil {
 			return err
 		}
@@ -374,20 +897,66 @@ func (p *TCPPeer) handleGossip(msg *Gossip) error {
 
 	case CommandType_CONSENSUS:
 		// received a consensus message from this peer
-		p.agent.handleConsensusMessage(msg.Message)
+		p.countConsensusReceived(msg.Message)
+		p.agent.handleConsensusMessage(msg.Message, p)
+	case CommandType_CLIENT_REQUEST:
+		// received a client request relayed by this peer
+		p.agent.handleClientRequest(msg.Message, p)
+	case CommandType_PROPOSAL:
+		// proposals are only accepted from authenticated peers
+		if p.GetPublicKey() == nil {
+			return nil
+		}
+
+		err := p.agent.handleProposal(msg.Message, p)
+		if err != nil {
+			return err
+		}
+	case CommandType_PING:
+		// pings are only replied to authenticated peers
+		if p.GetPublicKey() == nil {
+			return nil
+		}
+
+		err := p.handlePing(msg.Message)
+		if err != nil {
+			return err
+		}
+	case CommandType_PONG:
+		err := p.handlePong(msg.Message, time.Now())
+		if err != nil {
+			return err
+		}
+	case CommandType_PEER_RECORDS:
+		// peer records are only accepted from authenticated peers
+		if p.GetPublicKey() == nil {
+			return nil
+		}
+
+		err := p.agent.handlePeerRecords(msg.Message, p)
+		if err != nil {
+			return err
+		}
 	default:
-		panic(msg)
+		return ErrUnknownCommand
 	}
 	return nil
 }
 
-// peer initiated key authentication
-func (p *TCPPeer) handleKeyAuthInit(authKey *KeyAuthInit) error {
+// peer initiated key authentication, hello is the encoded KeyAuthInit as
+// received, to be mixed into session keys.
+func (p *TCPPeer) handleKeyAuthInit(authKey *KeyAuthInit, hello []byte) error {
 	p.Lock()
 	defer p.Unlock()
 	// only when in init status, authentication process cannot rollback
 	// to prevent from malicious re-authentication DoS
 	if p.peerAuthStatus == peerNotAuthenticated {
+		// reject incompatible peers early
+		if err := p.agent.checkHello(authKey); err != nil {
+			p.peerAuthStatus = peerAuthenticatedFailed
+			return err
+		}
+
 		peerPublicKey := &ecdsa.PublicKey{Curve: bdls.S256Curve, X: big.NewInt(0).SetBytes(authKey.X), Y: big.NewInt(0).SetBytes(authKey.Y)}
 
 		// on curve test
@@ -395,8 +964,23 @@ func (p *TCPPeer) handleKeyAuthInit(authKey *KeyAuthInit) error {
 			p.peerAuthStatus = peerAuthenticatedFailed
 			return ErrKeyNotOnCurve
 		}
+
+		// the announced key cannot be changed, check it against identity policy
+		if !p.agent.allowed(peerPublicKey) {
+			p.peerAuthStatus = peerAuthenticatedFailed
+			return ErrPeerIdentityRejected
+		}
+
+		// reject banned identities
+		if p.agent.banned(scoreKey{identity: bdls.DefaultPubKeyToIdentity(peerPublicKey)}) {
+			p.peerAuthStatus = peerAuthenticatedFailed
+			return ErrPeerBanned
+		}
 		// temporarily stored announced key
 		p.peerPublicKey = peerPublicKey
+		p.peerFeatures = authKey.Features
+		p.peerHeight = authKey.Height
+		p.peerHello = hello
 
 		// create ephermal key for authentication
 		ephemeral, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
@@ -405,6 +989,7 @@ func (p *TCPPeer) handleKeyAuthInit(authKey *KeyAuthInit) error {
 		}
 		// derive secret
 		secret := ECDH(p.peerPublicKey, ephemeral)
+		p.peerSecret = secret
 
 		// generate challenge texts
 		var challenge KeyAuthChallenge
@@ -438,7 +1023,7 @@ func (p *TCPPeer) handleKeyAuthInit(authKey *KeyAuthInit) error {
 		}
 
 		// enqueue
-		p.agentMessages = append(p.agentMessages, out)
+		p.handshakeMessages = append(p.handshakeMessages, out)
 		p.notifyAgentMessage()
 
 		// state shift
@@ -458,6 +1043,7 @@ func (p *TCPPeer) handleKeyAuthChallenge(challenge *KeyAuthChallenge) error {
 		pubkey := &ecdsa.PublicKey{Curve: bdls.S256Curve, X: big.NewInt(0).SetBytes(challenge.X), Y: big.NewInt(0).SetBytes(challenge.Y)}
 		// derive secret with my private key
 		secret := ECDH(pubkey, p.agent.privateKey)
+		p.localSecret = secret
 
 		// calculates HMAC for the challenge with the key above
 		var response KeyAuthChallengeReply
@@ -482,12 +1068,12 @@ func (p *TCPPeer) handleKeyAuthChallenge(challenge *KeyAuthChallenge) error {
 		}
 
 		// enqueue
-		p.agentMessages = append(p.agentMessages, out)
+		p.handshakeMessages = append(p.handshakeMessages, out)
 		p.notifyAgentMessage()
 
 		// state shift
 		p.localAuthState = localChallengeAccepted
-		return nil
+		return p.establishSession()
 	} else {
 		return ErrPeerKeyAuthChallenge
 	}
@@ -501,7 +1087,8 @@ func (p *TCPPeer) handleKeyAuthChallengeReply(response *KeyAuthChallengeReply) e
 		if subtle.ConstantTimeCompare(p.hmac, response.HMAC) == 1 {
 			p.hmac = nil
 			p.peerAuthStatus = peerAuthenticated
-			return nil
+			p.agent.linkAuthenticated(p)
+			return p.establishSession()
 		} else {
 			p.peerAuthStatus = peerAuthenticatedFailed
 			return ErrPeerAuthenticatedFailed
@@ -511,6 +1098,103 @@ func (p *TCPPeer) handleKeyAuthChallengeReply(response *KeyAuthChallengeReply) e
 	}
 }
 
+// establishSession derives session keys once both public keys have been
+// authenticated, the lock must be held.
+func (p *TCPPeer) establishSession() error {
+	if p.session != nil || p.peerAuthStatus != peerAuthenticated || p.localAuthState != localChallengeAccepted {
+		return nil
+	}
+
+	s, err := newSession(p.localSecret, p.peerSecret, p.localHello, p.peerHello)
+	if err != nil {
+		return err
+	}
+	p.session = s
+	p.localSecret = nil
+	p.peerSecret = nil
+	p.localHello = nil
+	p.peerHello = nil
+	p.sessionSeq = p.agent.nextSessionSeq()
+	go p.agent.sessionEstablished(p)
+
+	// messages held for the session
+	p.notifyConsensusMessage()
+	p.notifyAgentMessage()
+	return nil
+}
+
+// openGossip decodes a frame, sealed frames will be decrypted in session and
+// decompressed if compressed, only handshakes and NOP are allowed in
+// plaintext.
+func (p *TCPPeer) openGossip(bts []byte) (*Gossip, error) {
+	gossip := new(Gossip)
+	err := proto.Unmarshal(bts, gossip)
+	if err != nil {
+		return nil, err
+	}
+
+	switch gossip.Command {
+	case CommandType_NOP, CommandType_KEY_AUTH_INIT, CommandType_KEY_AUTH_CHALLENGE, CommandType_KEY_AUTH_CHALLENGE_REPLY:
+		return gossip, nil
+	case CommandType_SEALED:
+	default:
+		return nil, ErrFrameNotSealed
+	}
+
+	p.Lock()
+	s := p.session
+	p.Unlock()
+	if s == nil {
+		return nil, ErrSessionNotEstablished
+	}
+
+	// NOTE: only readLoop opens frames, the recv nonce needs no lock.
+	plaintext, err := s.open(gossip.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := new(Gossip)
+	err = proto.Unmarshal(plaintext, sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	// frames cannot be sealed twice
+	if sealed.Command == CommandType_SEALED {
+		return nil, ErrFrameNotSealed
+	}
+
+	if sealed.Command != CommandType_COMPRESSED {
+		return sealed, nil
+	}
+
+	// compressed frames are accepted only if we have announced the feature
+	p.Lock()
+	features := p.localFeatures
+	p.Unlock()
+	if features&FeatureDeflate == 0 {
+		return nil, ErrCompressionNotNegotiated
+	}
+
+	frame, err := decompressFrame(sealed.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	compressed := new(Gossip)
+	err = proto.Unmarshal(frame, compressed)
+	if err != nil {
+		return nil, err
+	}
+
+	// frames cannot be nested in compressed frames
+	if compressed.Command == CommandType_SEALED || compressed.Command == CommandType_COMPRESSED {
+		return nil, ErrFrameCompression
+	}
+	return compressed, nil
+}
+
 // readLoop keeps reading messages from peer
 func (p *TCPPeer) readLoop() {
 	defer p.Close()
@@ -525,18 +1209,21 @@ func (p *TCPPeer) readLoop() {
 			p.conn.SetReadDeadline(time.Now().Add(defaultReadTimeout))
 			_, err := io.ReadFull(p.conn, msgLength)
 			if err != nil {
+				p.recordError(err)
 				return
 			}
 
 			// check length
 			length := binary.LittleEndian.Uint32(msgLength)
 			if length > MaxMessageLength {
-				log.Println(err)
+				log.Println(ErrMessageLengthExceed)
+				p.misbehave(ErrMessageLengthExceed)
 				return
 			}
 
 			if length == 0 {
 				log.Println("zero length")
+				p.misbehave(ErrMessageLengthZero)
 				return
 			}
 
@@ -545,96 +1232,123 @@ func (p *TCPPeer) readLoop() {
 			bts := make([]byte, length)
 			_, err = io.ReadFull(p.conn, bts)
 			if err != nil {
+				p.recordError(err)
 				return
 			}
 
+			p.Lock()
+			p.countReceived(len(bts), time.Now())
+			p.Unlock()
+
 			// unmarshal bytes to message
-			var gossip Gossip
-			err = proto.Unmarshal(bts, &gossip)
+			gossip, err := p.openGossip(bts)
 			if err != nil {
 				log.Println(err)
+				p.misbehave(err)
 				return
 			}
 
-			err = p.handleGossip(&gossip)
+			err = p.handleGossip(gossip)
 			if err != nil {
 				log.Println(err)
+				p.misbehave(err)
 				return
 			}
 		}
 	}
 }
 
-// sendLoop keeps sending consensus message to this peer
+// sendLoop keeps sending consensus message to this peer, handshakes are
+// sent in plaintext, and other messages are sealed in session.
 func (p *TCPPeer) sendLoop() {
 	defer p.Close()
 
-	var pending [][]byte
-	var msg Gossip
-	msg.Command = CommandType_CONSENSUS
 	msgLength := make([]byte, MessageLength)
 
+	// write a frame
+	write := func(out []byte) error {
+		if len(out) > MaxMessageLength {
+			panic("maximum message size exceeded")
+		}
+
+		binary.LittleEndian.PutUint32(msgLength, uint32(len(out)))
+		p.conn.SetWriteDeadline(time.Now().Add(defaultWriteTimeout))
+		// write length
+		_, err := p.conn.Write(msgLength)
+		if err != nil {
+			return err
+		}
+
+		// write message
+		_, err = p.conn.Write(out)
+		if err != nil {
+			return err
+		}
+
+		p.Lock()
+		p.countSent(len(out))
+		p.Unlock()
+		return nil
+	}
+
+	// seal and write a frame in session
+	writeSealed := func(s *session, out []byte) error {
+		sealed, err := proto.Marshal(&Gossip{Command: CommandType_SEALED, Message: s.seal(out)})
+		if err != nil {
+			panic(err)
+		}
+		return write(sealed)
+	}
+
 	for {
 		select {
 		case <-p.chConsensusMessage:
-			p.Lock()
-			pending = p.consensusMessages
-			p.consensusMessages = nil
-			p.Unlock()
-
-			for _, bts := range pending {
-				// we need to encapsulate consensus messages
-				msg.Message = bts
-				out, err := proto.Marshal(&msg)
-				if err != nil {
-					panic(err)
-				}
+		case <-p.chAgentMessage:
+		case <-p.die:
+			return
+		}
 
-				if len(out) > MaxMessageLength {
-					panic("maximum message size exceeded")
-				}
+		// handshakes must be flushed before any sealed frame
+		p.Lock()
+		handshakes := p.handshakeMessages
+		p.handshakeMessages = nil
+		s := p.session
+		compress := p.localFeatures&p.peerFeatures&FeatureDeflate != 0
+		p.Unlock()
 
-				binary.LittleEndian.PutUint32(msgLength, uint32(len(out)))
-				p.conn.SetWriteDeadline(time.Now().Add(defaultWriteTimeout))
-				// write length
-				_, err = p.conn.Write(msgLength)
-				if err != nil {
-					log.Println(err)
-					return
-				}
-
-				// write message
-				_, err = p.conn.Write(out)
-				if err != nil {
-					log.Println(err)
-					return
-				}
+		for _, bts := range handshakes {
+			if err := write(bts); err != nil {
+				log.Println(err)
+				p.recordError(err)
+				return
 			}
-		case <-p.chAgentMessage:
+		}
+
+		// queued messages are held until session established, and sent one
+		// by one, so messages of higher priority queued meanwhile go first.
+		for s != nil {
 			p.Lock()
-			pending = p.agentMessages
-			p.agentMessages = nil
+			f, ok := p.queue.pop()
 			p.Unlock()
+			if !ok {
+				break
+			}
 
-			for _, bts := range pending {
-				binary.LittleEndian.PutUint32(msgLength, uint32(len(bts)))
-				// write length
-				_, err := p.conn.Write(msgLength)
-				if err != nil {
-					log.Println(err)
-					return
-				}
+			// compress frames if both sides support
+			out := f.frame
+			if compress && len(out) >= p.compressionThreshold {
+				out = compressFrame(out)
+			}
 
-				// write message
-				_, err = p.conn.Write(bts)
-				if err != nil {
-					log.Println(err)
-					return
-				}
+			if err := writeSealed(s, out); err != nil {
+				log.Println(err)
+				p.recordError(err)
+				return
 			}
 
-		case <-p.die:
-			return
+			if f.consensus {
+				p.countConsensusSent(f.mtype)
+			}
 		}
 	}
 }
@@ -218,3 +218,287 @@ func testConsensus(t *testing.T, param *testParam) {
 
 	t.Logf("consensus stopped at height:%v for %v peers %v participants", param.stopHeight, param.numPeers, param.numParticipants)
 }
+
+func TestClientRequestRelay(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	var coords []bdls.Identity
+	for i := 0; i < 4; i++ {
+		privateKey, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+		assert.Nil(t, err)
+		keys = append(keys, privateKey)
+		coords = append(coords, bdls.DefaultPubKeyToIdentity(&privateKey.PublicKey))
+	}
+
+	// agents connected in a line: 0 - 1 - 2
+	var agents []*TCPAgent
+	for i := 0; i < 3; i++ {
+		config := new(bdls.Config)
+		config.Epoch = time.Now()
+		config.PrivateKey = keys[i]
+		config.Participants = coords
+		config.StateCompare = func(a bdls.State, b bdls.State) int { return bytes.Compare(a, b) }
+		config.StateValidate = func(a bdls.State) bool { return true }
+
+		consensus, err := bdls.NewConsensus(config)
+		assert.Nil(t, err)
+		agents = append(agents, NewTCPAgent(consensus, keys[i]))
+		defer agents[i].Close()
+	}
+
+	// requests are sent in session, peers must authenticate
+	for i := 0; i < 2; i++ {
+		c1, c2 := net.Pipe()
+		p1 := NewTCPPeer(c1, agents[i])
+		p2 := NewTCPPeer(c2, agents[i+1])
+		assert.True(t, agents[i].AddPeer(p1))
+		assert.True(t, agents[i+1].AddPeer(p2))
+		assert.Nil(t, p1.InitiatePublicKeyAuthentication())
+		assert.Nil(t, p2.InitiatePublicKeyAuthentication())
+	}
+
+	clientKey, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	r := new(bdls.ClientRequest)
+	r.ID = 1
+	r.ExpiryHeight = 1
+	r.Payload = []byte("payload")
+	r.Sign(clientKey)
+	bts, err := r.Marshal()
+	assert.Nil(t, err)
+
+	assert.Nil(t, agents[0].SubmitRequest(bts))
+	assert.Equal(t, bdls.ErrRequestDuplicated, agents[0].SubmitRequest(bts))
+
+	// the request will be relayed to all agents
+	deadline := time.Now().Add(5 * time.Second)
+	for _, agent := range agents {
+		for len(agent.PendingRequests()) == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("request has not been relayed in time")
+			}
+			<-time.After(10 * time.Millisecond)
+		}
+		assert.Equal(t, r.Payload, agent.PendingRequests()[0].Payload)
+	}
+}
+
+// createAgents creates n agents of participants which are not connected,
+// states with non-zero length are valid.
+func createAgents(t *testing.T, n int) []*TCPAgent {
+	var keys []*ecdsa.PrivateKey
+	var coords []bdls.Identity
+	for i := 0; i < 4 || i < n; i++ {
+		privateKey, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+		assert.Nil(t, err)
+		keys = append(keys, privateKey)
+		coords = append(coords, bdls.DefaultPubKeyToIdentity(&privateKey.PublicKey))
+	}
+
+	var agents []*TCPAgent
+	for i := 0; i < n; i++ {
+		config := new(bdls.Config)
+		config.Epoch = time.Now()
+		config.PrivateKey = keys[i]
+		config.Participants = coords
+		config.StateCompare = func(a bdls.State, b bdls.State) int { return bytes.Compare(a, b) }
+		config.StateValidate = func(a bdls.State) bool { return len(a) > 0 }
+
+		consensus, err := bdls.NewConsensus(config)
+		assert.Nil(t, err)
+		agents = append(agents, NewTCPAgent(consensus, keys[i]))
+	}
+	return agents
+}
+
+// connectPipe connects two agents with a pipe, and initiates authentication
+func connectPipe(t *testing.T, a *TCPAgent, b *TCPAgent) (*TCPPeer, *TCPPeer) {
+	c1, c2 := net.Pipe()
+	p1 := NewTCPPeer(c1, a)
+	p2 := NewTCPPeer(c2, b)
+	assert.True(t, a.AddPeer(p1))
+	assert.True(t, b.AddPeer(p2))
+	assert.Nil(t, p1.InitiatePublicKeyAuthentication())
+	assert.Nil(t, p2.InitiatePublicKeyAuthentication())
+	return p1, p2
+}
+
+// createLineAgents creates agents connected in a line with authenticated
+// peers: 0 - 1 - ... - (n-1)
+func createLineAgents(t *testing.T, n int) []*TCPAgent {
+	agents := createAgents(t, n)
+	var peers []*TCPPeer
+	for i := 0; i < n-1; i++ {
+		p1, p2 := connectPipe(t, agents[i], agents[i+1])
+		peers = append(peers, p1, p2)
+	}
+
+	// wait for authentication
+	deadline := time.Now().Add(5 * time.Second)
+	for _, p := range peers {
+		for p.GetPublicKey() == nil {
+			if time.Now().After(deadline) {
+				t.Fatal("peers have not authenticated in time")
+			}
+			<-time.After(10 * time.Millisecond)
+		}
+	}
+	return agents
+}
+
+func TestProposalGossip(t *testing.T) {
+	agents := createLineAgents(t, 3)
+	for _, agent := range agents {
+		defer agent.Close()
+	}
+
+	// invalid proposals will be dropped by peers, and oversized ones rejected
+	agents[0].Propose(bdls.State{})
+	assert.Equal(t, ErrProposalSize, agents[1].handleProposal(make(bdls.State, MaxProposalSize+1), nil))
+
+	proposal := bdls.State("proposal")
+	agents[0].Propose(proposal)
+	agents[0].Propose(proposal)
+
+	// the proposal will be relayed to all agents
+	hasProposed := func(agent *TCPAgent, s bdls.State) bool {
+		agent.Lock()
+		defer agent.Unlock()
+		return agent.consensus.HasProposed(s)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for _, agent := range agents {
+		for !hasProposed(agent, proposal) {
+			if time.Now().After(deadline) {
+				t.Fatal("proposal has not been relayed in time")
+			}
+			<-time.After(10 * time.Millisecond)
+		}
+	}
+
+	for _, agent := range agents[1:] {
+		assert.False(t, hasProposed(agent, bdls.State{}))
+	}
+}
+
+func TestProposalLimits(t *testing.T) {
+	agents := createLineAgents(t, 3)
+	for _, agent := range agents {
+		defer agent.Close()
+	}
+	agent := agents[1]
+	agent.Lock()
+	from, other := agent.peers[0], agent.peers[1]
+	agent.Unlock()
+
+	hasProposed := func(s bdls.State) bool {
+		agent.Lock()
+		defer agent.Unlock()
+		return agent.consensus.HasProposed(s)
+	}
+
+	setQuota := func(p *TCPPeer, count int, bytes int) {
+		agent.Lock()
+		defer agent.Unlock()
+		agent.syncProposals()
+		agent.proposalQuotas[peerScoreKey(p)] = &proposalQuota{count: count, bytes: bytes}
+	}
+
+	// invalid proposals are penalized, and not seen
+	assert.Nil(t, agent.handleProposal(bdls.State{}, from))
+	assert.False(t, hasProposed(bdls.State{}))
+	assert.InDelta(t, PenaltyInvalidProposal, agent.peerScore(from), 0.1)
+	agent.Lock()
+	assert.Equal(t, 0, len(agent.proposals))
+	assert.Equal(t, 0, len(agent.proposalsValidating))
+	agent.Unlock()
+
+	// count of proposals from a peer at a height
+	setQuota(from, MaxProposalsPerHeight, 0)
+	assert.Nil(t, agent.handleProposal(bdls.State("count"), from))
+	assert.False(t, hasProposed(bdls.State("count")))
+
+	// which limits no other peers, nor local proposals
+	assert.Nil(t, agent.handleProposal(bdls.State("count"), other))
+	assert.True(t, hasProposed(bdls.State("count")))
+	agent.Propose(bdls.State("local"))
+	assert.True(t, hasProposed(bdls.State("local")))
+
+	// bytes of proposals from a peer at a height
+	setQuota(from, 0, MaxProposalBytesPerHeight-1)
+	assert.Nil(t, agent.handleProposal(bdls.State("ab"), from))
+	assert.False(t, hasProposed(bdls.State("ab")))
+	assert.Nil(t, agent.handleProposal(bdls.State("a"), from))
+	assert.True(t, hasProposed(bdls.State("a")))
+
+	// proposals being validated are not admitted again
+	agent.Lock()
+	defer agent.Unlock()
+	height, ok := agent.admitProposal(bdls.State("validating"), other)
+	assert.True(t, ok)
+	_, ok = agent.admitProposal(bdls.State("validating"), other)
+	assert.False(t, ok)
+
+	// nor marked after the height has changed
+	assert.False(t, agent.markValidatedProposal(bdls.State("validating"), height+1, true))
+	assert.True(t, agent.markValidatedProposal(bdls.State("validating"), height, true))
+	assert.False(t, agent.markProposal(bdls.State("validating")))
+}
+
+func TestIdentityPolicy(t *testing.T) {
+	agents := createLineAgents(t, 2)
+	for _, agent := range agents {
+		defer agent.Close()
+	}
+
+	outsider, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	participants := ParticipantsPolicy(agents[0].consensus)
+	assert.True(t, participants(&agents[1].privateKey.PublicKey))
+	assert.False(t, participants(&outsider.PublicKey))
+
+	allowlist := AllowlistPolicy([]bdls.Identity{bdls.DefaultPubKeyToIdentity(&outsider.PublicKey)})
+	assert.True(t, allowlist(&outsider.PublicKey))
+	assert.False(t, allowlist(&agents[1].privateKey.PublicKey))
+
+	// authenticated peers not allowed will be disconnected
+	agents[0].Lock()
+	p := agents[0].peers[0]
+	agents[0].Unlock()
+	agents[0].SetIdentityPolicy(allowlist)
+	select {
+	case <-p.die:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection has not been closed")
+	}
+
+	// new connections with unknown identity will be rejected
+	c1, c2 := net.Pipe()
+	p1 := NewTCPPeer(c1, agents[0])
+	p2 := NewTCPPeer(c2, agents[1])
+	assert.True(t, agents[0].AddPeer(p1))
+	assert.True(t, agents[1].AddPeer(p2))
+	assert.Nil(t, p2.InitiatePublicKeyAuthentication())
+	select {
+	case <-p1.die:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection has not been closed")
+	}
+	assert.Nil(t, p1.GetPublicKey())
+}
+
+func TestAuthTimeout(t *testing.T) {
+	agents := createLineAgents(t, 1)
+	defer agents[0].Close()
+	agents[0].SetAuthTimeout(100 * time.Millisecond)
+
+	// connections not authenticated in time will be dropped
+	c1, _ := net.Pipe()
+	p := NewTCPPeer(c1, agents[0])
+	assert.True(t, agents[0].AddPeer(p))
+	select {
+	case <-p.die:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection has not been closed")
+	}
+}
@@ -0,0 +1,179 @@
+package bdls
+
+import "bytes"
+
+// Application defines a replicated state machine on top of consensus, states
+// decided by consensus will be applied to the application in height order.
+type Application interface {
+	// CheckProposal validates a proposed state, it backs Config.StateValidate.
+	CheckProposal(s State) bool
+	// BuildProposal builds a state to propose at the given height on
+	// demand, it backs Config.ProposalProvider. nil can be returned if
+	// there is nothing to propose.
+	BuildProposal(height uint64) State
+	// ApplyState applies a decided state at the given height, the height
+	// is always LastHeight() + 1.
+	ApplyState(height uint64, s State) error
+	// LastHeight returns the height of the last applied state, it MUST be
+	// persisted along with the applied states to resume after restart.
+	LastHeight() uint64
+	// AppHash returns the hash of the application state after the last
+	// applied state, replicas which have applied the same states MUST
+	// return the same hash.
+	AppHash() []byte
+}
+
+// AppDriver connects an Application to Consensus, decided states will be
+// applied exactly once and in height order.
+//
+// As Consensus, AppDriver is not thread-safe, it's driven by the consensus
+// object it created, users should take care of their own synchronization.
+type AppDriver struct {
+	app       Application
+	consensus *Consensus
+
+	// height of the last applied state
+	lastHeight uint64
+	// the error which stops applying states
+	err error
+}
+
+// NewAppDriver creates a consensus object with a copy of the config and
+// connects it to the application.
+//
+// The copy's StateValidate, ProposalProvider and CurrentHeight will be set
+// from the application, and DecideCallback will be wrapped to apply decided states
+// after the previous callback, consensus resumes from the application's
+// LastHeight, so states applied before restart won't be applied again.
+func NewAppDriver(app Application, c *Config) (*AppDriver, error) {
+	d := new(AppDriver)
+	d.app = app
+	d.lastHeight = app.LastHeight()
+
+	config := new(Config)
+	*config = *c
+
+	config.StateValidate = app.CheckProposal
+	config.ProposalProvider = app.BuildProposal
+	config.CurrentHeight = d.lastHeight
+
+	callback := config.DecideCallback
+	config.DecideCallback = func(height uint64, round uint64, s State, proof *SignedProto) {
+		if callback != nil {
+			callback(height, round, s, proof)
+		}
+		d.decided(height, round, s, proof)
+	}
+
+	consensus, err := NewConsensus(config)
+	if err != nil {
+		return nil, err
+	}
+	d.consensus = consensus
+	return d, nil
+}
+
+// Consensus returns the consensus object driven by this driver
+func (d *AppDriver) Consensus() *Consensus { return d.consensus }
+
+// Application returns the application of this driver
+func (d *AppDriver) Application() Application { return d.app }
+
+// LastHeight returns the height of the last applied state
+func (d *AppDriver) LastHeight() uint64 { return d.lastHeight }
+
+// AppHash returns the hash of the application state after the last
+// applied state
+func (d *AppDriver) AppHash() []byte { return d.app.AppHash() }
+
+// Err returns the error which stopped applying states, if any.
+// ErrAppHeightGap will be returned if consensus has jumped over some
+// heights, the application needs to fetch the missing states along with
+// their <decide> proofs by other means, and feed them to CatchUp.
+func (d *AppDriver) Err() error { return d.err }
+
+// CatchUp applies a state decided at height LastHeight() + 1 with the
+// <decide> proof of it, states fetched from other nodes can be applied
+// with this method to recover from ErrAppHeightGap, and the driver will
+// resume applying decided states once it has caught up with consensus.
+// The state is validated synchronously with StateValidate even in async
+// validation mode.
+//
+// As the driver, CatchUp must not be called concurrently with consensus.
+func (d *AppDriver) CatchUp(height uint64, s State, proof *SignedProto) error {
+	if height <= d.lastHeight {
+		return nil
+	}
+
+	if height != d.lastHeight+1 {
+		return ErrAppHeightGap
+	}
+
+	if err := d.verifyProof(height, s, proof); err != nil {
+		return err
+	}
+
+	if err := d.app.ApplyState(height, s); err != nil {
+		return err
+	}
+	d.lastHeight = height
+
+	// caught up with consensus
+	if d.err == ErrAppHeightGap {
+		if latestHeight, _, _ := d.consensus.CurrentState(); d.lastHeight >= latestHeight {
+			d.err = nil
+		}
+	}
+	return nil
+}
+
+// verifyProof verifies the proof which decides the state at the height
+func (d *AppDriver) verifyProof(height uint64, s State, proof *SignedProto) error {
+	c := d.consensus
+	if proof == nil || proof.Version != ProtocolVersion {
+		return ErrMessageVersion
+	}
+
+	m, err := c.verifyMessage(proof)
+	if err != nil {
+		return err
+	}
+
+	if m.Type != MessageType_Decide {
+		return ErrMessageUnknownMessageType
+	}
+
+	if m.Height != height {
+		return ErrAppProofHeight
+	}
+
+	if !bytes.Equal(m.State, s) {
+		return ErrMismatchedTargetState
+	}
+
+	return c.verifyDecideProof(m, proof, c.validateStateSync)
+}
+
+// decided is the DecideCallback for consensus
+func (d *AppDriver) decided(height uint64, round uint64, s State, proof *SignedProto) {
+	if d.err != nil {
+		return
+	}
+
+	// already applied
+	if height <= d.lastHeight {
+		return
+	}
+
+	// states can only be applied in height order
+	if height != d.lastHeight+1 {
+		d.err = ErrAppHeightGap
+		return
+	}
+
+	if err := d.app.ApplyState(height, s); err != nil {
+		d.err = err
+		return
+	}
+	d.lastHeight = height
+}
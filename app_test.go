@@ -0,0 +1,267 @@
+package bdls
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BDLS-bft/bdls/crypto/blake2b"
+	"github.com/stretchr/testify/assert"
+)
+
+// memApp is an in-memory application which chains the hashes of states
+type memApp struct {
+	id      int
+	heights []uint64
+	states  []State
+	hash    []byte
+	failAt  uint64
+	sync.Mutex
+}
+
+func (app *memApp) CheckProposal(s State) bool { return len(s) > 0 }
+func (app *memApp) BuildProposal(height uint64) State {
+	return State(fmt.Sprintf("proposal from %v at height %v", app.id, height))
+}
+
+func (app *memApp) ApplyState(height uint64, s State) error {
+	app.Lock()
+	defer app.Unlock()
+	if height == app.failAt {
+		return errors.New("apply failed")
+	}
+	h := blake2b.Sum256(append(app.hash, s...))
+	app.hash = h[:]
+	app.heights = append(app.heights, height)
+	app.states = append(app.states, s)
+	return nil
+}
+
+func (app *memApp) LastHeight() uint64 {
+	app.Lock()
+	defer app.Unlock()
+	if len(app.heights) == 0 {
+		return 0
+	}
+	return app.heights[len(app.heights)-1]
+}
+
+func (app *memApp) AppHash() []byte {
+	app.Lock()
+	defer app.Unlock()
+	return app.hash
+}
+
+// replayApp creates an application by applying the states from scratch
+func replayApp(t *testing.T, states ...State) *memApp {
+	app := new(memApp)
+	for k := range states {
+		assert.Nil(t, app.ApplyState(uint64(k+1), states[k]))
+	}
+	return app
+}
+
+func createAppConfig(privateKey *ecdsa.PrivateKey, participants []Identity) *Config {
+	config := new(Config)
+	config.Epoch = time.Now()
+	config.PrivateKey = privateKey
+	config.Participants = participants
+	config.StateCompare = func(a State, b State) int { return bytes.Compare(a, b) }
+	return config
+}
+
+func TestAppDriver(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	var participants []Identity
+	for i := 0; i < 4; i++ {
+		privateKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+		assert.Nil(t, err)
+		keys = append(keys, privateKey)
+		participants = append(participants, DefaultPubKeyToIdentity(&privateKey.PublicKey))
+	}
+
+	var apps []*memApp
+	var drivers []*AppDriver
+	var peers []*IPCPeer
+	for i := range keys {
+		app := &memApp{id: i}
+		driver, err := NewAppDriver(app, createAppConfig(keys[i], participants))
+		assert.Nil(t, err)
+		driver.Consensus().SetLatency(50 * time.Millisecond)
+		apps = append(apps, app)
+		drivers = append(drivers, driver)
+		peers = append(peers, NewIPCPeer(driver.Consensus(), 50*time.Millisecond))
+	}
+
+	for i := range peers {
+		for j := range peers {
+			if i != j {
+				peers[i].c.Join(peers[j])
+			}
+		}
+	}
+
+	for i := range peers {
+		peers[i].Update()
+		defer peers[i].Close()
+	}
+
+	const heights = 3
+	deadline := time.Now().Add(30 * time.Second)
+	for i := range apps {
+		for apps[i].LastHeight() < heights {
+			if time.Now().After(deadline) {
+				t.Fatal("application has not applied in time")
+			}
+			<-time.After(20 * time.Millisecond)
+		}
+	}
+
+	// every application applies the same states exactly once in height order
+	for i := range apps {
+		apps[i].Lock()
+		for k := 0; k < heights; k++ {
+			assert.Equal(t, uint64(k+1), apps[i].heights[k])
+			assert.Equal(t, apps[0].states[k], apps[i].states[k])
+		}
+		apps[i].Unlock()
+	}
+
+	// and ends with the same hash as replaying the states from scratch
+	for i := range apps {
+		apps[i].Lock()
+		states := apps[i].states
+		hash := apps[i].hash
+		apps[i].Unlock()
+		assert.Equal(t, replayApp(t, states...).AppHash(), hash)
+		assert.NotNil(t, drivers[i].AppHash())
+	}
+}
+
+func TestAppDriverRestart(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	var participants []Identity
+	for i := 0; i < 4; i++ {
+		participants = append(participants, DefaultPubKeyToIdentity(&privateKey.PublicKey))
+	}
+
+	app := replayApp(t, State{1}, State{2}, State{3})
+	driver, err := NewAppDriver(app, createAppConfig(privateKey, participants))
+	assert.Nil(t, err)
+
+	// consensus resumes from the last applied height
+	height, _, _ := driver.Consensus().CurrentState()
+	assert.Equal(t, uint64(3), height)
+	assert.Equal(t, uint64(3), driver.LastHeight())
+	assert.True(t, driver.Consensus().HasProposed(app.BuildProposal(4)))
+
+	// applied heights will be ignored
+	hash := driver.AppHash()
+	driver.decided(3, 0, State{3}, nil)
+	assert.Equal(t, 3, len(app.states))
+	assert.Equal(t, hash, driver.AppHash())
+	assert.Nil(t, driver.Err())
+
+	driver.decided(4, 0, State{4}, nil)
+	assert.Equal(t, uint64(4), driver.LastHeight())
+	assert.Equal(t, 4, len(app.states))
+	assert.Equal(t, replayApp(t, State{1}, State{2}, State{3}, State{4}).AppHash(), driver.AppHash())
+
+	// gaps stop applying
+	driver.decided(6, 0, State{6}, nil)
+	assert.Equal(t, ErrAppHeightGap, driver.Err())
+	driver.decided(5, 0, State{5}, nil)
+	assert.Equal(t, uint64(4), driver.LastHeight())
+}
+
+func TestAppDriverApplyError(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	var participants []Identity
+	for i := 0; i < 4; i++ {
+		participants = append(participants, DefaultPubKeyToIdentity(&privateKey.PublicKey))
+	}
+
+	app := &memApp{failAt: 1}
+	driver, err := NewAppDriver(app, createAppConfig(privateKey, participants))
+	assert.Nil(t, err)
+
+	driver.decided(1, 0, State{1}, nil)
+	assert.NotNil(t, driver.Err())
+	assert.Equal(t, uint64(0), driver.LastHeight())
+}
+
+func TestAppDriverCatchUp(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	var participants []Identity
+	for i := 0; i < 4; i++ {
+		privateKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+		assert.Nil(t, err)
+		keys = append(keys, privateKey)
+		participants = append(participants, DefaultPubKeyToIdentity(&privateKey.PublicKey))
+	}
+
+	app := new(memApp)
+	driver, err := NewAppDriver(app, createAppConfig(keys[0], participants))
+	assert.Nil(t, err)
+	driver.Consensus().SetLeader(&keys[0].PublicKey)
+
+	// <decide> proof with <commit>s from all participants
+	decide := func(height uint64, s State) *SignedProto {
+		m := Message{Type: MessageType_Decide, Height: height, State: s}
+		for _, key := range keys {
+			_, commit, _ := createCommitMessageSigner(t, height, 0, s, key)
+			m.Proof = append(m.Proof, commit)
+		}
+		signed := new(SignedProto)
+		signed.Sign(&m, keys[0])
+		return signed
+	}
+
+	// consensus jumps from height 1 to 3
+	driver.decided(1, 0, State{1}, nil)
+	driver.Consensus().latestHeight = 3
+	driver.decided(3, 0, State{3}, nil)
+	assert.Equal(t, ErrAppHeightGap, driver.Err())
+
+	assert.Equal(t, ErrAppHeightGap, driver.CatchUp(3, State{3}, decide(3, State{3})))
+	assert.Equal(t, ErrMismatchedTargetState, driver.CatchUp(2, State{3}, decide(2, State{2})))
+	assert.Equal(t, ErrAppProofHeight, driver.CatchUp(2, State{3}, decide(3, State{3})))
+	assert.Equal(t, uint64(1), driver.LastHeight())
+
+	// catch up the missing heights
+	assert.Nil(t, driver.CatchUp(2, State{2}, decide(2, State{2})))
+	assert.Equal(t, ErrAppHeightGap, driver.Err())
+	assert.Nil(t, driver.CatchUp(3, State{3}, decide(3, State{3})))
+	assert.Nil(t, driver.Err())
+	assert.Equal(t, []State{{1}, {2}, {3}}, app.states)
+	assert.Equal(t, replayApp(t, State{1}, State{2}, State{3}).AppHash(), driver.AppHash())
+
+	// decided states are applied again
+	driver.decided(4, 0, State{4}, nil)
+	assert.Equal(t, uint64(4), driver.LastHeight())
+}
+
+func TestAppDriverConfigCopy(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	var participants []Identity
+	for i := 0; i < 4; i++ {
+		participants = append(participants, DefaultPubKeyToIdentity(&privateKey.PublicKey))
+	}
+	config := createAppConfig(privateKey, participants)
+	_, err = NewAppDriver(&memApp{heights: []uint64{3}, states: []State{{3}}}, config)
+	assert.Nil(t, err)
+
+	// the caller's config is left untouched
+	assert.Nil(t, config.StateValidate)
+	assert.Nil(t, config.ProposalProvider)
+	assert.Nil(t, config.DecideCallback)
+	assert.Equal(t, uint64(0), config.CurrentHeight)
+}
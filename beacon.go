@@ -0,0 +1,214 @@
+package bdls
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"sort"
+
+	"github.com/BDLS-bft/bdls/crypto/blake2b"
+)
+
+const (
+	// BeaconSize defines byte size of a random beacon
+	BeaconSize = blake2b.Size256
+	// BeaconPrefix is the domain separator for deriving a random beacon
+	BeaconPrefix = "BDLS_RANDOM_BEACON"
+)
+
+// Beacon defines a per-height random value derived from a <decide> message.
+//
+// WARNING: the beacon is unpredictable until the height is decided, but it
+// is BIASABLE, the signers can regrind their ECDSA nonces and the leader
+// chooses the <commit>s in the certificate, so they can select among many
+// candidate beacons. DO NOT use it where a biased value gives an advantage,
+// like committee sampling or leader election, use a threshold signature or
+// VRF based beacon for those instead.
+type Beacon [BeaconSize]byte
+
+// String returns hex representation of the beacon
+func (b Beacon) String() string { return hex.EncodeToString(b[:]) }
+
+// Beacon derives the random beacon from a <decide> message as follows:
+// blake2b(BeaconPrefix + [len_32bit(chainid) + chainid] + height + subset(X + Y + len_32bit(r) + r + len_32bit(low_s) + low_s))
+//
+// only the <commit> proofs to the decided state are taken into account, one
+// per signer, and the subset is fixed to the 2t+1 signers with the smallest
+// identities, s is normalized to the lower half of the curve order to remove
+// the malleability, so every node holding the same <decide> message computes
+// the same beacon, and extra <commit>s in the certificate make no difference.
+//
+// NOTE: ECDSA signatures are not unique, a signer could regrind its nonce,
+// and the leader chooses which <commit>s are in the certificate, so the
+// beacon is unpredictable before the <decide> message, but it is biasable,
+// see Beacon. The beacon is not supported with EnableFastPath, as every
+// participant decides with its own certificate.
+func (c *Consensus) Beacon(signed *SignedProto) (Beacon, error) {
+	var beacon Beacon
+	if c.enableFastPath {
+		return beacon, ErrBeaconFastPath
+	}
+
+	m, err := DecodeMessage(signed.Message)
+	if err != nil {
+		return beacon, err
+	}
+
+	if m.Type != MessageType_Decide {
+		return beacon, ErrBeaconNotDecideMessage
+	}
+
+	// collect <commit> signatures to the decided state, one per signer
+	type commit struct {
+		identity Identity
+		proof    *SignedProto
+	}
+	mHash := c.stateHash(m.State)
+	seen := make(map[Identity]bool)
+	var commits []commit
+	for _, proof := range m.Proof {
+		mProof, err := DecodeMessage(proof.Message)
+		if err != nil {
+			return beacon, err
+		}
+
+		if mProof.Type != MessageType_Commit || c.stateHash(mProof.State) != mHash {
+			continue
+		}
+
+		identity := c.pubKeyToIdentity(proof.PublicKey(c.curve))
+		if seen[identity] {
+			continue
+		}
+		seen[identity] = true
+		commits = append(commits, commit{identity, proof})
+	}
+
+	// fix the subset to 2t+1 signers with the smallest identities
+	if len(commits) < 2*c.t()+1 {
+		return beacon, ErrBeaconCommits
+	}
+	sort.Slice(commits, func(i, j int) bool {
+		return bytes.Compare(commits[i].identity[:], commits[j].identity[:]) < 0
+	})
+	commits = commits[:2*c.t()+1]
+
+	hash, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	// write prefix
+	_, err = hash.Write([]byte(BeaconPrefix))
+	if err != nil {
+		panic(err)
+	}
+
+	// write chain id
+	if len(c.chainID) > 0 {
+		err = binary.Write(hash, binary.LittleEndian, uint32(len(c.chainID)))
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = hash.Write(c.chainID)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// write height
+	err = binary.Write(hash, binary.LittleEndian, m.Height)
+	if err != nil {
+		panic(err)
+	}
+
+	// write signatures
+	halfOrder := new(big.Int).Rsh(c.curve.Params().N, 1)
+	for _, commit := range commits {
+		proof := commit.proof
+		_, err = hash.Write(proof.X[:])
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = hash.Write(proof.Y[:])
+		if err != nil {
+			panic(err)
+		}
+
+		err = binary.Write(hash, binary.LittleEndian, uint32(len(proof.R)))
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = hash.Write(proof.R)
+		if err != nil {
+			panic(err)
+		}
+
+		// low s
+		s := new(big.Int).SetBytes(proof.S)
+		if s.Cmp(halfOrder) > 0 {
+			s.Sub(c.curve.Params().N, s)
+		}
+		lowS := s.Bytes()
+
+		err = binary.Write(hash, binary.LittleEndian, uint32(len(lowS)))
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = hash.Write(lowS)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	copy(beacon[:], hash.Sum(nil))
+	return beacon, nil
+}
+
+// CurrentBeacon returns the random beacon for current height, it returns
+// false if no <decide> message has been seen yet, or with EnableFastPath.
+// The beacon is biasable, see Beacon.
+func (c *Consensus) CurrentBeacon() (Beacon, bool) {
+	if c.latestProof == nil {
+		return Beacon{}, false
+	}
+
+	beacon, err := c.Beacon(c.latestProof)
+	if err != nil {
+		return Beacon{}, false
+	}
+	return beacon, true
+}
+
+// ValidateBeacon validates a beacon along with the <decide> message for
+// non-participants(light clients), the consensus core must be correctly
+// initialized to validate. A valid beacon is only proven to be derived from
+// the decided certificate, it's still biasable, see Beacon. States are
+// validated synchronously, see ValidateDecideMessage.
+// the targetState is to compare the target state enclosed in decide message
+func (c *Consensus) ValidateBeacon(bts []byte, targetState []byte, beacon Beacon) error {
+	signed, err := DecodeSignedMessage(bts)
+	if err != nil {
+		return err
+	}
+
+	err = c.validateDecideMessage(signed, targetState)
+	if err != nil {
+		return err
+	}
+
+	derived, err := c.Beacon(signed)
+	if err != nil {
+		return err
+	}
+
+	if derived != beacon {
+		return ErrBeaconMismatch
+	}
+	return nil
+}
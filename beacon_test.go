@@ -0,0 +1,124 @@
+package bdls
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBeaconDeterministic(t *testing.T) {
+	m, sp, privateKey, proofKeys := createDecideMessage(t, 20, 10, 10, 10, 10)
+	consensus := createConsensus(t, 9, 10, proofKeys)
+	consensus.SetLeader(&privateKey.PublicKey)
+
+	beacon, err := consensus.Beacon(sp)
+	assert.Nil(t, err)
+
+	// the order of proofs and the proofs to other states make no difference
+	valid := 2*consensus.t() + 1
+	var reversed []*SignedProto
+	for i := valid - 1; i >= 0; i-- {
+		reversed = append(reversed, m.Proof[i])
+	}
+	m.Proof = reversed
+	resigned := new(SignedProto)
+	resigned.Sign(m, privateKey)
+
+	another, err := consensus.Beacon(resigned)
+	assert.Nil(t, err)
+	assert.Equal(t, beacon, another)
+
+	// nor the malleated s of a signature
+	malleated := *m.Proof[0]
+	sig := new(big.Int).SetBytes(malleated.S)
+	malleated.S = sig.Sub(S256Curve.Params().N, sig).Bytes()
+	m.Proof[0] = &malleated
+	resigned.Sign(m, privateKey)
+	another, err = consensus.Beacon(resigned)
+	assert.Nil(t, err)
+	assert.Equal(t, beacon, another)
+
+	// but less than 2t+1 <commit>s cannot derive a beacon
+	m.Proof = m.Proof[1:]
+	resigned.Sign(m, privateKey)
+	_, err = consensus.Beacon(resigned)
+	assert.Equal(t, ErrBeaconCommits, err)
+}
+
+func TestBeaconFastPath(t *testing.T) {
+	_, sp, _, proofKeys := createDecideMessage(t, 20, 10, 10, 10, 10)
+	consensus := createConsensus(t, 9, 10, proofKeys)
+	consensus.enableFastPath = true
+
+	_, err := consensus.Beacon(sp)
+	assert.Equal(t, ErrBeaconFastPath, err)
+}
+
+func TestBeaconChainID(t *testing.T) {
+	_, sp, _, proofKeys := createDecideMessage(t, 20, 10, 10, 10, 10)
+	consensus := createConsensus(t, 9, 10, proofKeys)
+
+	beacon, err := consensus.Beacon(sp)
+	assert.Nil(t, err)
+
+	consensus.chainID = []byte("another")
+	another, err := consensus.Beacon(sp)
+	assert.Nil(t, err)
+	assert.NotEqual(t, beacon, another)
+}
+
+func TestBeaconNotDecideMessage(t *testing.T) {
+	_, sp, _ := createCommitMessage(t, 10, 10, make([]byte, 1024))
+	consensus := createConsensus(t, 9, 10, nil)
+
+	_, err := consensus.Beacon(sp)
+	assert.Equal(t, ErrBeaconNotDecideMessage, err)
+}
+
+func TestValidateBeacon(t *testing.T) {
+	m, sp, privateKey, proofKeys := createDecideMessage(t, 20, 10, 10, 10, 10)
+	consensus := createConsensus(t, 9, 10, proofKeys)
+	consensus.SetLeader(&privateKey.PublicKey)
+
+	beacon, err := consensus.Beacon(sp)
+	assert.Nil(t, err)
+
+	bts, err := proto.Marshal(sp)
+	assert.Nil(t, err)
+	assert.Nil(t, consensus.ValidateBeacon(bts, m.State, beacon))
+
+	beacon[0] ^= 0xff
+	assert.Equal(t, ErrBeaconMismatch, consensus.ValidateBeacon(bts, m.State, beacon))
+
+	// the proof itself must be valid
+	consensus = createConsensus(t, 9, 10, nil)
+	assert.NotNil(t, consensus.ValidateBeacon(bts, m.State, beacon))
+}
+
+func TestCurrentBeacon(t *testing.T) {
+	_, sp, privateKey, proofKeys := createDecideMessage(t, 20, 10, 10, 10, 10)
+	consensus := createConsensus(t, 9, 10, proofKeys)
+	consensus.SetLeader(&privateKey.PublicKey)
+
+	// no <decide> message seen yet
+	_, ok := consensus.CurrentBeacon()
+	assert.False(t, ok)
+
+	bts, err := proto.Marshal(sp)
+	assert.Nil(t, err)
+	assert.Nil(t, consensus.ReceiveMessage(bts, time.Now()))
+
+	expected, err := consensus.Beacon(sp)
+	assert.Nil(t, err)
+	beacon, ok := consensus.CurrentBeacon()
+	assert.True(t, ok)
+	assert.Equal(t, expected, beacon)
+
+	// not supported with fast path
+	consensus.enableFastPath = true
+	_, ok = consensus.CurrentBeacon()
+	assert.False(t, ok)
+}
@@ -0,0 +1,192 @@
+package chain
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/binary"
+	"math/big"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+	"github.com/BDLS-bft/bdls/crypto/blake2b"
+)
+
+const (
+	// HeaderSize defines byte size of an encoded block header
+	HeaderSize = 8 + blake2b.Size256 + 8 + 2*bdls.SizeAxis + blake2b.Size256
+	// SignatureSize defines byte size of an encoded proposer signature
+	SignatureSize = 2 * bdls.SizeAxis
+	// HeaderSignPrefix is the domain separator for signing block headers
+	HeaderSignPrefix = "BDLS_CHAIN_HEADER"
+)
+
+// Header is the canonical block header, encoded in fixed size as follows:
+// |Height(8bytes)|ParentHash(32bytes)|Timestamp(8bytes)|Proposer(64bytes)|PayloadRoot(32bytes)|
+// integers are encoded in little endian.
+//
+// NOTE: states are relayed by others in consensus messages, the signer of a
+// message is not the proposer of the block in it, so the proposer is only
+// trustworthy along with the proposer signature in Block.
+type Header struct {
+	Height      uint64         // height of this block
+	ParentHash  bdls.StateHash // hash of the parent block header
+	Timestamp   int64          // unix time in nanoseconds while proposing
+	Proposer    bdls.Identity  // public key(X, Y) of the proposer
+	PayloadRoot bdls.StateHash // hash of the block payload
+}
+
+// Marshal encodes the header in canonical form
+func (h *Header) Marshal() []byte {
+	bts := make([]byte, HeaderSize)
+	h.MarshalTo(bts)
+	return bts
+}
+
+// MarshalTo encodes the header to data, data must have at least HeaderSize bytes
+func (h *Header) MarshalTo(data []byte) {
+	binary.LittleEndian.PutUint64(data, h.Height)
+	data = data[8:]
+	copy(data, h.ParentHash[:])
+	data = data[len(h.ParentHash):]
+	binary.LittleEndian.PutUint64(data, uint64(h.Timestamp))
+	data = data[8:]
+	copy(data, h.Proposer[:])
+	data = data[len(h.Proposer):]
+	copy(data, h.PayloadRoot[:])
+}
+
+// Unmarshal decodes the header from canonical form
+func (h *Header) Unmarshal(data []byte) error {
+	if len(data) != HeaderSize {
+		return ErrHeaderSize
+	}
+
+	h.Height = binary.LittleEndian.Uint64(data)
+	data = data[8:]
+	copy(h.ParentHash[:], data)
+	data = data[len(h.ParentHash):]
+	h.Timestamp = int64(binary.LittleEndian.Uint64(data))
+	data = data[8:]
+	copy(h.Proposer[:], data)
+	data = data[len(h.Proposer):]
+	copy(h.PayloadRoot[:], data)
+	return nil
+}
+
+// Hash returns the hash of the canonical header, which identifies the block
+func (h *Header) Hash() bdls.StateHash { return blake2b.Sum256(h.Marshal()) }
+
+// Time returns the timestamp of the header as time.Time
+func (h *Header) Time() time.Time { return time.Unix(0, h.Timestamp) }
+
+// SignHash returns the hash for the proposer to sign as follows:
+// blake2b(HeaderSignPrefix + Header)
+func (h *Header) SignHash() []byte {
+	hash, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = hash.Write([]byte(HeaderSignPrefix))
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = hash.Write(h.Marshal())
+	if err != nil {
+		panic(err)
+	}
+	return hash.Sum(nil)
+}
+
+// ProposerKey returns the public key of the proposer on the curve
+func (h *Header) ProposerKey(curve elliptic.Curve) *ecdsa.PublicKey {
+	pubkey := new(ecdsa.PublicKey)
+	pubkey.Curve = curve
+	pubkey.X = new(big.Int).SetBytes(h.Proposer[:bdls.SizeAxis])
+	pubkey.Y = new(big.Int).SetBytes(h.Proposer[bdls.SizeAxis:])
+	return pubkey
+}
+
+// PayloadRoot computes the payload root of a payload
+func PayloadRoot(payload []byte) bdls.StateHash { return blake2b.Sum256(payload) }
+
+// Block is a block header along with the proposer signature over the header
+// and its payload, a block is encoded as a bdls.State as follows:
+// |Header(HeaderSize)|R(32bytes)|S(32bytes)|Payload|
+type Block struct {
+	Header
+	R, S    *big.Int // proposer signature over Header.SignHash()
+	Payload []byte
+}
+
+// NewBlock creates a child block of the parent header, signed by the
+// proposer.
+func NewBlock(parent *Header, proposer *ecdsa.PrivateKey, timestamp time.Time, payload []byte) *Block {
+	b := new(Block)
+	b.Height = parent.Height + 1
+	b.ParentHash = parent.Hash()
+	b.Timestamp = timestamp.UnixNano()
+	b.PayloadRoot = PayloadRoot(payload)
+	b.Payload = payload
+	b.Sign(proposer)
+	return b
+}
+
+// Sign sets the proposer of the header, and signs the header
+func (b *Block) Sign(proposer *ecdsa.PrivateKey) {
+	b.Proposer = bdls.DefaultPubKeyToIdentity(&proposer.PublicKey)
+	r, s, err := ecdsa.Sign(rand.Reader, proposer, b.SignHash())
+	if err != nil {
+		panic(err)
+	}
+	b.R, b.S = r, s
+}
+
+// Verify verifies the proposer signature over the header
+func (b *Block) Verify(curve elliptic.Curve) bool {
+	if b.R == nil || b.S == nil {
+		return false
+	}
+
+	pubkey := b.ProposerKey(curve)
+	if !curve.IsOnCurve(pubkey.X, pubkey.Y) {
+		return false
+	}
+	return ecdsa.Verify(pubkey, b.SignHash(), b.R, b.S)
+}
+
+// Marshal encodes the block as a state
+func (b *Block) Marshal() bdls.State {
+	s := make([]byte, HeaderSize+SignatureSize+len(b.Payload))
+	b.Header.MarshalTo(s)
+	if b.R != nil && b.S != nil {
+		b.R.FillBytes(s[HeaderSize : HeaderSize+bdls.SizeAxis])
+		b.S.FillBytes(s[HeaderSize+bdls.SizeAxis : HeaderSize+SignatureSize])
+	}
+	copy(s[HeaderSize+SignatureSize:], b.Payload)
+	return s
+}
+
+// DecodeBlock decodes a block from a state, and verifies the payload
+// against the payload root, the proposer signature is not verified.
+func DecodeBlock(s bdls.State) (*Block, error) {
+	if len(s) < HeaderSize+SignatureSize {
+		return nil, ErrHeaderSize
+	}
+
+	b := new(Block)
+	err := b.Header.Unmarshal(s[:HeaderSize])
+	if err != nil {
+		return nil, err
+	}
+
+	b.R = new(big.Int).SetBytes(s[HeaderSize : HeaderSize+bdls.SizeAxis])
+	b.S = new(big.Int).SetBytes(s[HeaderSize+bdls.SizeAxis : HeaderSize+SignatureSize])
+	b.Payload = s[HeaderSize+SignatureSize:]
+	if PayloadRoot(b.Payload) != b.PayloadRoot {
+		return nil, ErrPayloadRoot
+	}
+	return b, nil
+}
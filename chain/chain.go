@@ -0,0 +1,177 @@
+package chain
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"sync"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+	"github.com/BDLS-bft/bdls/crypto/blake2b"
+)
+
+// Chain tracks the last decided block header, to validate and compare
+// candidate blocks for the next height.
+type Chain struct {
+	last     Header         // last decided header
+	lastHash bdls.StateHash // hash of the last decided header
+
+	// allowed proposers, nil to allow any
+	participants     map[bdls.Identity]bool
+	pubKeyToIdentity func(pubkey *ecdsa.PublicKey) bdls.Identity
+	curve            elliptic.Curve
+	sync.Mutex
+}
+
+// NewChain creates a chain on top of the last decided header, which is the
+// genesis header for a new chain.
+func NewChain(last Header) *Chain {
+	ch := new(Chain)
+	ch.last = last
+	ch.lastHash = last.Hash()
+	ch.pubKeyToIdentity = bdls.DefaultPubKeyToIdentity
+	ch.curve = bdls.S256Curve
+	return ch
+}
+
+// Setup plugs the chain into the config, StateCompare and CurrentHeight
+// will be set, StateValidate will be wrapped so a state must pass both the
+// previous validation and Validate, and DecideCallback will be wrapped to
+// track the decided blocks. Proposers will be restricted to the participants.
+//
+// NOTE: bdls.AppDriver sets StateValidate from the application, the
+// application's CheckProposal should call Validate to use with it.
+func (ch *Chain) Setup(config *bdls.Config) {
+	ch.Lock()
+	ch.participants = make(map[bdls.Identity]bool)
+	for _, id := range config.Participants {
+		ch.participants[id] = true
+	}
+	if config.PubKeyToIdentity != nil {
+		ch.pubKeyToIdentity = config.PubKeyToIdentity
+	}
+	if config.PrivateKey != nil {
+		ch.curve = config.PrivateKey.Curve
+	}
+	config.CurrentHeight = ch.last.Height
+	ch.Unlock()
+
+	config.StateCompare = ch.Compare
+
+	validate := config.StateValidate
+	config.StateValidate = func(s bdls.State) bool {
+		if !ch.Validate(s) {
+			return false
+		}
+		return validate == nil || validate(s)
+	}
+
+	callback := config.DecideCallback
+	config.DecideCallback = func(height uint64, round uint64, s bdls.State, proof *bdls.SignedProto) {
+		ch.decided(s)
+		if callback != nil {
+			callback(height, round, s, proof)
+		}
+	}
+}
+
+// decided moves the chain to a decided block
+func (ch *Chain) decided(s bdls.State) {
+	b, err := DecodeBlock(s)
+	if err != nil {
+		return
+	}
+
+	ch.Lock()
+	defer ch.Unlock()
+	if b.Height > ch.last.Height {
+		ch.last = b.Header
+		ch.lastHash = b.Header.Hash()
+	}
+}
+
+// Last returns the last decided block header
+func (ch *Chain) Last() Header {
+	ch.Lock()
+	defer ch.Unlock()
+	return ch.last
+}
+
+// NewBlock creates a block on top of the last decided block, signed by the
+// proposer.
+func (ch *Chain) NewBlock(proposer *ecdsa.PrivateKey, timestamp time.Time, payload []byte) *Block {
+	last := ch.Last()
+	return NewBlock(&last, proposer, timestamp, payload)
+}
+
+// Validate is a StateValidate function, a valid block must be the child of
+// the last decided block, signed by a participant as the proposer, and not
+// earlier than its parent.
+//
+// NOTE: as blocks are validated against the last decided block, a <decide>
+// message for heights ahead cannot be validated, nodes lagging behind need
+// to catch up by other means.
+func (ch *Chain) Validate(s bdls.State) bool {
+	b, err := DecodeBlock(s)
+	if err != nil {
+		return false
+	}
+
+	curve, ok := ch.validateHeader(&b.Header)
+	if !ok {
+		return false
+	}
+
+	// verify the signature without holding the lock
+	return b.Verify(curve)
+}
+
+// validateHeader validates the header against the last decided block and the
+// participants, and returns the curve to verify the proposer signature.
+func (ch *Chain) validateHeader(h *Header) (elliptic.Curve, bool) {
+	ch.Lock()
+	defer ch.Unlock()
+	if h.Height != ch.last.Height+1 {
+		return nil, false
+	}
+
+	if h.ParentHash != ch.lastHash {
+		return nil, false
+	}
+
+	if h.Timestamp < ch.last.Timestamp {
+		return nil, false
+	}
+
+	if ch.participants != nil && !ch.participants[ch.pubKeyToIdentity(h.ProposerKey(ch.curve))] {
+		return nil, false
+	}
+	return ch.curve, true
+}
+
+// Compare is a StateCompare function, blocks are ordered by height first,
+// then by the hash of the encoded block, so every node orders candidates
+// identically. Malformed blocks are smaller than any valid block.
+func (ch *Chain) Compare(a bdls.State, b bdls.State) int {
+	ba, errA := DecodeBlock(a)
+	bb, errB := DecodeBlock(b)
+	switch {
+	case errA != nil && errB != nil:
+		return bytes.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+
+	if ba.Height < bb.Height {
+		return -1
+	} else if ba.Height > bb.Height {
+		return 1
+	}
+
+	ha := blake2b.Sum256(a)
+	hb := blake2b.Sum256(b)
+	return bytes.Compare(ha[:], hb[:])
+}
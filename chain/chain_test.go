@@ -0,0 +1,193 @@
+package chain
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderMarshal(t *testing.T) {
+	h := Header{Height: 10, Timestamp: time.Now().UnixNano()}
+	h.ParentHash[0] = 1
+	h.Proposer[0] = 2
+	h.PayloadRoot = PayloadRoot([]byte("payload"))
+
+	bts := h.Marshal()
+	assert.Equal(t, HeaderSize, len(bts))
+
+	var decoded Header
+	assert.Nil(t, decoded.Unmarshal(bts))
+	assert.Equal(t, h, decoded)
+	assert.Equal(t, h.Hash(), decoded.Hash())
+	assert.Equal(t, ErrHeaderSize, decoded.Unmarshal(bts[1:]))
+}
+
+func TestDecodeBlock(t *testing.T) {
+	proposer, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+
+	genesis := Header{}
+	b := NewBlock(&genesis, proposer, time.Now(), []byte("payload"))
+	decoded, err := DecodeBlock(b.Marshal())
+	assert.Nil(t, err)
+	assert.Equal(t, b.Header, decoded.Header)
+	assert.Equal(t, b.Payload, decoded.Payload)
+	assert.Equal(t, uint64(1), decoded.Height)
+	assert.Equal(t, genesis.Hash(), decoded.ParentHash)
+	assert.Equal(t, bdls.DefaultPubKeyToIdentity(&proposer.PublicKey), decoded.Proposer)
+	assert.True(t, decoded.Verify(bdls.S256Curve))
+
+	s := b.Marshal()
+	s[len(s)-1] ^= 0xff
+	_, err = DecodeBlock(s)
+	assert.Equal(t, ErrPayloadRoot, err)
+
+	_, err = DecodeBlock(s[:HeaderSize+SignatureSize-1])
+	assert.Equal(t, ErrHeaderSize, err)
+
+	// the signature covers the header
+	s = b.Marshal()
+	s[0] ^= 0xff
+	decoded, err = DecodeBlock(s)
+	assert.Nil(t, err)
+	assert.False(t, decoded.Verify(bdls.S256Curve))
+}
+
+func TestValidate(t *testing.T) {
+	proposer, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	outsider, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+
+	genesis := Header{Timestamp: time.Now().UnixNano()}
+	ch := NewChain(genesis)
+
+	// the previous validation is kept
+	config := new(bdls.Config)
+	config.Participants = []bdls.Identity{bdls.DefaultPubKeyToIdentity(&proposer.PublicKey)}
+	config.StateValidate = func(s bdls.State) bool {
+		b, err := DecodeBlock(s)
+		return err == nil && len(b.Payload) == 0
+	}
+	ch.Setup(config)
+
+	now := time.Now()
+	assert.True(t, ch.Validate(ch.NewBlock(proposer, now, nil).Marshal()))
+	assert.True(t, config.StateValidate(ch.NewBlock(proposer, now, nil).Marshal()))
+	assert.True(t, ch.Validate(ch.NewBlock(proposer, now, []byte("payload")).Marshal()))
+	assert.False(t, config.StateValidate(ch.NewBlock(proposer, now, []byte("payload")).Marshal()))
+	// unknown proposer
+	assert.False(t, ch.Validate(ch.NewBlock(outsider, now, nil).Marshal()))
+	// a participant as the proposer without its signature
+	b := ch.NewBlock(outsider, now, nil)
+	b.Proposer = bdls.DefaultPubKeyToIdentity(&proposer.PublicKey)
+	assert.False(t, ch.Validate(b.Marshal()))
+	// earlier than parent
+	assert.False(t, ch.Validate(ch.NewBlock(proposer, genesis.Time().Add(-time.Second), nil).Marshal()))
+	// not a child of the last block
+	b = ch.NewBlock(proposer, now, nil)
+	b.ParentHash[0] ^= 0xff
+	b.Sign(proposer)
+	assert.False(t, ch.Validate(b.Marshal()))
+	b = ch.NewBlock(proposer, now, nil)
+	assert.False(t, ch.Validate(NewBlock(&b.Header, proposer, now, nil).Marshal()))
+	// malformed
+	assert.False(t, ch.Validate([]byte("malformed")))
+
+	// move to the next height
+	b = ch.NewBlock(proposer, now, nil)
+	config.DecideCallback(1, 0, b.Marshal(), nil)
+	assert.Equal(t, b.Header, ch.Last())
+	assert.False(t, ch.Validate(b.Marshal()))
+	assert.True(t, ch.Validate(ch.NewBlock(proposer, now, nil).Marshal()))
+}
+
+func TestCompare(t *testing.T) {
+	proposer, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+
+	genesis := Header{}
+	ch := NewChain(genesis)
+	a := NewBlock(&genesis, proposer, time.Now(), []byte("a")).Marshal()
+	b := NewBlock(&genesis, proposer, time.Now(), []byte("b")).Marshal()
+	higher := NewBlock(&Header{Height: 1}, proposer, time.Now(), []byte("c")).Marshal()
+
+	assert.Equal(t, 0, ch.Compare(a, a))
+	assert.Equal(t, -ch.Compare(a, b), ch.Compare(b, a))
+	assert.Equal(t, -1, ch.Compare(a, higher))
+	assert.Equal(t, 1, ch.Compare(higher, b))
+	assert.Equal(t, -1, ch.Compare([]byte("malformed"), a))
+	assert.Equal(t, 1, ch.Compare(a, []byte("malformed")))
+}
+
+func TestChainConsensus(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	var participants []bdls.Identity
+	for i := 0; i < 4; i++ {
+		privateKey, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+		assert.Nil(t, err)
+		keys = append(keys, privateKey)
+		participants = append(participants, bdls.DefaultPubKeyToIdentity(&privateKey.PublicKey))
+	}
+
+	genesis := Header{Timestamp: time.Now().UnixNano()}
+	var chains []*Chain
+	var consensuses []*bdls.Consensus
+	var peers []*bdls.IPCPeer
+	epoch := time.Now()
+	for i := range keys {
+		ch := NewChain(genesis)
+		config := new(bdls.Config)
+		config.Epoch = epoch
+		config.PrivateKey = keys[i]
+		config.Participants = participants
+		ch.Setup(config)
+
+		consensus, err := bdls.NewConsensus(config)
+		assert.Nil(t, err)
+		consensus.SetLatency(50 * time.Millisecond)
+		chains = append(chains, ch)
+		consensuses = append(consensuses, consensus)
+		peers = append(peers, bdls.NewIPCPeer(consensus, 50*time.Millisecond))
+	}
+
+	for i := range peers {
+		for j := range peers {
+			if i != j {
+				consensuses[i].Join(peers[j])
+			}
+		}
+	}
+
+	for i := range peers {
+		peers[i].Update()
+		defer peers[i].Close()
+	}
+
+	const heights = 3
+	for height := uint64(1); height <= heights; height++ {
+		for i := range peers {
+			payload := []byte(fmt.Sprint(i, height))
+			peers[i].Propose(chains[i].NewBlock(keys[i], time.Now(), payload).Marshal())
+		}
+
+		deadline := time.Now().Add(30 * time.Second)
+		for i := range chains {
+			for chains[i].Last().Height < height {
+				if time.Now().After(deadline) {
+					t.Fatal("chain has not decided in time")
+				}
+				<-time.After(20 * time.Millisecond)
+			}
+		}
+	}
+
+	for i := range chains {
+		assert.Equal(t, chains[0].Last(), chains[i].Last())
+	}
+}
@@ -0,0 +1,5 @@
+// Package chain provides an optional block data model for BDLS consensus,
+// blocks are linked to their parents by header hash and signed by their
+// proposers, and the chain plugs StateCompare and StateValidate into
+// bdls.Config.
+package chain
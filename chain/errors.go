@@ -0,0 +1,8 @@
+package chain
+
+import "errors"
+
+var (
+	ErrHeaderSize  = errors.New("incorrect block header size")
+	ErrPayloadRoot = errors.New("the payload does not match the payload root in header")
+)
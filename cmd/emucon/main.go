@@ -1,7 +1,6 @@
 package main
 
 import (
-	"bytes"
 	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/hex"
@@ -17,6 +16,7 @@ import (
 
 	"github.com/BDLS-bft/bdls"
 	"github.com/BDLS-bft/bdls/agent-tcp"
+	"github.com/BDLS-bft/bdls/chain"
 	"github.com/BDLS-bft/bdls/crypto/blake2b"
 	"github.com/urfave/cli/v2"
 
@@ -140,6 +140,16 @@ func main() {
 						Value: "./peers.json",
 						Usage: "all peers's ip:port list to connect, as a json array",
 					},
+					&cli.StringFlag{
+						Name:  "advertise",
+						Value: "",
+						Usage: "the ip:port advertised to peers for discovery, not advertised if empty",
+					},
+					&cli.StringFlag{
+						Name:  "addrbook",
+						Value: "",
+						Usage: "the file to persist discovered peers, not persisted if empty",
+					},
 				},
 				Action: func(c *cli.Context) error {
 					// open quorum config
@@ -164,9 +174,6 @@ func main() {
 					// create configuration
 					config := new(bdls.Config)
 					config.Epoch = time.Now()
-					config.CurrentHeight = 0
-					config.StateCompare = func(a bdls.State, b bdls.State) int { return bytes.Compare(a, b) }
-					config.StateValidate = func(bdls.State) bool { return true }
 
 					for k := range quorum.Keys {
 						priv := new(ecdsa.PrivateKey)
@@ -182,7 +189,11 @@ func main() {
 						config.Participants = append(config.Participants, bdls.DefaultPubKeyToIdentity(&priv.PublicKey))
 					}
 
-					if err := startConsensus(c, config); err != nil {
+					// blocks on top of an empty genesis block
+					ch := chain.NewChain(chain.Header{})
+					ch.Setup(config)
+
+					if err := startConsensus(c, config, ch); err != nil {
 						return err
 					}
 					return nil
@@ -204,7 +215,7 @@ func main() {
 }
 
 // consensus for one round with full procedure
-func startConsensus(c *cli.Context, config *bdls.Config) error {
+func startConsensus(c *cli.Context, config *bdls.Config, ch *chain.Chain) error {
 	// create consensus
 	consensus, err := bdls.NewConsensus(config)
 	if err != nil {
@@ -247,39 +258,24 @@ func startConsensus(c *cli.Context, config *bdls.Config) error {
 	// start updater
 	tagent.Update()
 
-	// passive connection from peers
-	go func() {
-		for {
-			conn, err := l.Accept()
-			if err != nil {
-				return
-			}
-			log.Println("peer connected from:", conn.RemoteAddr())
-			// peer endpoint created
-			p := agent.NewTCPPeer(conn, tagent)
-			tagent.AddPeer(p)
-			// prove my identity to this peer
-			p.InitiatePublicKeyAuthentication()
+	// discovered peers from last run
+	if c.String("addrbook") != "" {
+		if err := tagent.OpenAddressBook(c.String("addrbook")); err != nil {
+			return err
 		}
-	}()
+	}
+
+	// announce my address to peers
+	if c.String("advertise") != "" {
+		tagent.SetAdvertiseAddress(c.String("advertise"))
+	}
 
-	// active connections to peers
+	// passive connection from peers
+	go tagent.Serve(l)
+
+	// active connections to peers, redialing on disconnection
 	for k := range peers {
-		go func(raddr string) {
-			for {
-				conn, err := net.Dial("tcp", raddr)
-				if err == nil {
-					log.Println("connected to peer:", conn.RemoteAddr())
-					// peer endpoint created
-					p := agent.NewTCPPeer(conn, tagent)
-					tagent.AddPeer(p)
-					// prove my identity to this peer
-					p.InitiatePublicKeyAuthentication()
-					return
-				}
-				<-time.After(time.Second)
-			}
-		}(peers[k])
+		tagent.AddAddress(peers[k])
 	}
 
 	lastHeight := uint64(0)
@@ -288,7 +284,7 @@ NEXTHEIGHT:
 	for {
 		data := make([]byte, 1024)
 		io.ReadFull(rand.Reader, data)
-		tagent.Propose(data)
+		tagent.Propose(ch.NewBlock(config.PrivateKey, time.Now(), data).Marshal())
 
 		for {
 			newHeight, newRound, newState := tagent.GetLatestState()
@@ -25,6 +25,15 @@ type Config struct {
 	// if not(by default), <commit> message will be broadcasted
 	EnableCommitUnicast bool
 
+	// EnableFastPath sets to true to let every participant collect broadcasted
+	// <commit> messages, a participant which has seen 2t+1 <commit> messages
+	// to the locked state decides locally with a <decide> certificate signed
+	// by itself, without waiting for the leader's <decide> message, the
+	// certificate is broadcasted for participants who have missed <commit>s.
+	// It cannot be used along with EnableCommitUnicast, and the random beacon
+	// is not supported as certificates differ among participants.
+	EnableFastPath bool
+
 	// StateCompare is a function from user to compare states,
 	// The result will be 0 if a==b, -1 if a < b, and +1 if a > b.
 	// Usually this will lead to block header comparsion in blockchain, or replication log in database,
@@ -35,12 +44,57 @@ type Config struct {
 	// state data.
 	StateValidate func(State) bool
 
+	// AsyncValidation sets to true to call StateValidate off the state machine,
+	// messages carrying states which have not been validated will be parked,
+	// and processed again once the verdicts are known, verdicts are memoized
+	// by StateHash within a height. At most runtime.NumCPU() states are
+	// validated concurrently, and messages will be rejected with
+	// ErrStateValidationLimit if too many states are validating at a height.
+	// StateValidate MUST be thread-safe in this mode, and Update should be
+	// called on Consensus.ValidationNotify().
+	AsyncValidation bool
+
 	// MessageValidator is an external validator to be called when a message inputs into ReceiveMessage
 	MessageValidator func(c *Consensus, m *Message, signed *SignedProto) bool
 
 	// MessageOutCallback will be called if not nil before a message send out
 	MessageOutCallback func(m *Message, signed *SignedProto)
 
+	// ParticipationCallback will be called if not nil when a new height has
+	// been decided, with the participation report of that height.
+	ParticipationCallback func(report *ParticipationReport)
+
+	// DecideCallback will be called if not nil when a new height has been
+	// decided, with the decided state and the <decide> message to prove it,
+	// states proposed in this callback will be proposed at the next height.
+	DecideCallback func(height uint64, round uint64, s State, proof *SignedProto)
+
+	// ProposalProvider will be called if not nil when this node needs a
+	// state for its <roundchange> message, and no state has been proposed
+	// or locked at the height. The returned state will be proposed, or nil
+	// if there is nothing to propose.
+	ProposalProvider func(height uint64) State
+
+	// RequestExtractor returns the signed client requests included in a
+	// state(optional), decided requests will be removed from pending
+	// requests, and receipts will be issued for those submitted to this node.
+	RequestExtractor func(s State) []*ClientRequest
+
+	// ReceiptCallback will be called if not nil for every client request
+	// included in a decided state, after DecideCallback.
+	ReceiptCallback func(receipt *Receipt)
+
+	// FutureHeights sets how many heights ahead <roundchange>, <lock>, <select>,
+	// <lock-release> and <commit> messages will be buffered for(optional),
+	// buffered messages will be processed again once this node has reached
+	// that height. Default to 0, which disables buffering.
+	FutureHeights uint64
+
+	// ChainID identifies the network this consensus runs on(optional), it's
+	// mixed into the signature of every message, messages signed for
+	// another chain id will be rejected.
+	ChainID []byte
+
 	// Identity derviation from ecdsa.PublicKey
 	// (optional). Default to DefaultPubKeyToIdentity
 	PubKeyToIdentity func(pubkey *ecdsa.PublicKey) (ret Identity)
@@ -68,5 +122,9 @@ func VerifyConfig(c *Config) error {
 		return ErrConfigParticipants
 	}
 
+	if c.EnableFastPath && c.EnableCommitUnicast {
+		return ErrConfigFastPath
+	}
+
 	return nil
 }
@@ -42,4 +42,9 @@ func TestVerifyConfig(t *testing.T) {
 
 	err = VerifyConfig(config)
 	assert.Nil(t, err)
+
+	config.EnableFastPath = true
+	config.EnableCommitUnicast = true
+	err = VerifyConfig(config)
+	assert.Equal(t, ErrConfigFastPath, err)
 }
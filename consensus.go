@@ -27,6 +27,10 @@ const (
 
 	// MaxConsensusLatency is the ceiling of latencies
 	MaxConsensusLatency = 10 * time.Second
+
+	// MaxFutureMessages is the maximum number of messages buffered for
+	// future heights from a single participant
+	MaxFutureMessages = 64
 )
 
 type (
@@ -60,6 +64,14 @@ type messageTuple struct {
 	Signed    *SignedProto // the encoded message with signature
 }
 
+// futureMessage is a message for future heights, awaiting to be processed
+// while this node reaches that height.
+type futureMessage struct {
+	Identity Identity // the signer of this message
+	Height   uint64   // the height of this message
+	Raw      []byte   // the original message
+}
+
 // a sorter for messageTuple slice
 type tupleSorter struct {
 	tuples []messageTuple
@@ -250,7 +262,16 @@ type Consensus struct {
 	latestRound  uint64       // latest confirmed round
 	latestProof  *SignedProto // latest <decide> message to prove the state
 
-	unconfirmed []State // data awaiting to be confirmed at next height
+	latestReport  *ParticipationReport // participation report of the latest height
+	roundsEntered []uint64             // rounds entered at current height, for the report
+
+	// client requests
+	pendingRequests []*pendingRequest             // requests awaiting to be included
+	pendingIndex    map[RequestID]*pendingRequest // index of pending requests
+	decidedRequests map[RequestID]uint64          // decided requests along with their expiry heights
+
+	unconfirmed      []State            // data awaiting to be confirmed at next height
+	unconfirmedIndex map[StateHash]bool // index of unconfirmed data by hash, created on demand
 
 	rounds       list.List       // all rounds at next height(consensus round in progress)
 	currentRound *consensusRound // current round which has collected >=2t+1 <roundchange>
@@ -268,13 +289,28 @@ type Consensus struct {
 	stateCompare func(State, State) int
 	// the StateValidate function from config
 	stateValidate func(State) bool
+	// asynchronous state validation, nil if disabled
+	asyncValidator *asyncValidator
 	// message in callback
 	messageValidator func(c *Consensus, m *Message, sp *SignedProto) bool
 	// message out callback
 	messageOutCallback func(m *Message, sp *SignedProto)
+	// participation report callback
+	participationCallback func(report *ParticipationReport)
+	// decided state callback
+	decideCallback func(height uint64, round uint64, s State, proof *SignedProto)
+	// proposal provider for <roundchange>
+	proposalProvider func(height uint64) State
+	// client request ids extractor from decided states
+	requestExtractor func(s State) []*ClientRequest
+	// client request receipt callback
+	receiptCallback func(receipt *Receipt)
 	// public key to identity function
 	pubKeyToIdentity func(pubkey *ecdsa.PublicKey) Identity
 
+	// the chain id mixed into message signatures
+	chainID []byte
+
 	// the StateHash function to identify a state
 	stateHash func(State) StateHash
 
@@ -300,6 +336,9 @@ type Consensus struct {
 	// set to true to enable <commit> message unicast
 	enableCommitUnicast bool
 
+	// set to true to decide locally on 2t+1 <commit> messages
+	enableFastPath bool
+
 	// NOTE: fixed leader for testing purpose
 	fixedLeader *Identity
 
@@ -308,6 +347,13 @@ type Consensus struct {
 
 	// the last message which caused round change
 	lastRoundChangeProof []*SignedProto
+
+	// messages buffered for future heights, in the order of arrival
+	futureMessages []futureMessage
+	// count of buffered messages for each participant
+	futureCounts map[Identity]int
+	// max heights ahead to buffer messages for
+	futureHeights uint64
 }
 
 // NewConsensus creates a BDLS consensus object to participant in consensus procedure,
@@ -332,11 +378,25 @@ func (c *Consensus) init(config *Config) {
 	c.participants = config.Participants
 	c.stateCompare = config.StateCompare
 	c.stateValidate = config.StateValidate
+	if config.AsyncValidation {
+		c.asyncValidator = newAsyncValidator(config.StateValidate, c.latestHeight)
+	}
 	c.messageValidator = config.MessageValidator
 	c.messageOutCallback = config.MessageOutCallback
+	c.participationCallback = config.ParticipationCallback
+	c.decideCallback = config.DecideCallback
+	c.proposalProvider = config.ProposalProvider
+	c.requestExtractor = config.RequestExtractor
+	c.receiptCallback = config.ReceiptCallback
+	c.pendingIndex = make(map[RequestID]*pendingRequest)
+	c.decidedRequests = make(map[RequestID]uint64)
 	c.privateKey = config.PrivateKey
 	c.pubKeyToIdentity = config.PubKeyToIdentity
 	c.enableCommitUnicast = config.EnableCommitUnicast
+	c.enableFastPath = config.EnableFastPath
+	c.chainID = config.ChainID
+	c.futureHeights = config.FutureHeights
+	c.futureCounts = make(map[Identity]int)
 
 	// if config has not set hash function, use the default
 	if c.stateHash == nil {
@@ -465,6 +525,11 @@ func (c *Consensus) verifyMessage(signed *SignedProto) (*Message, error) {
 		return nil, ErrMessageUnknownParticipant
 	}
 
+	// check the message has been signed for this chain
+	if !bytes.Equal(signed.ChainID, c.chainID) {
+		return nil, ErrMessageChainID
+	}
+
 	/*
 		// public key validation
 		p := defaultCurve.Params().P
@@ -506,8 +571,8 @@ func (c *Consensus) verifyRoundChangeMessage(m *Message) error {
 
 	// state data validation for non-null <roundchange>
 	if m.State != nil {
-		if !c.stateValidate(m.State) {
-			return ErrRoundChangeStateValidation
+		if err := c.validateState(m.State, ErrRoundChangeStateValidation); err != nil {
+			return err
 		}
 	}
 
@@ -534,8 +599,8 @@ func (c *Consensus) verifyLockMessage(m *Message, signed *SignedProto) error {
 	}
 
 	// state data validation
-	if !c.stateValidate(m.State) {
-		return ErrLockStateValidation
+	if err := c.validateState(m.State, ErrLockStateValidation); err != nil {
+		return err
 	}
 
 	// make sure this message has been signed by the leader
@@ -573,8 +638,8 @@ func (c *Consensus) verifyLockMessage(m *Message, signed *SignedProto) error {
 
 		// state data validation in proofs
 		if mProof.State != nil {
-			if !c.stateValidate(mProof.State) {
-				return ErrLockProofStateValidation
+			if err := c.validateState(mProof.State, ErrLockProofStateValidation); err != nil {
+				return err
 			}
 		}
 
@@ -638,8 +703,8 @@ func (c *Consensus) verifySelectMessage(m *Message, signed *SignedProto) error {
 
 	// state data validation for non-null <select>
 	if m.State != nil {
-		if !c.stateValidate(m.State) {
-			return ErrSelectStateValidation
+		if err := c.validateState(m.State, ErrSelectStateValidation); err != nil {
+			return err
 		}
 	}
 
@@ -673,8 +738,8 @@ func (c *Consensus) verifySelectMessage(m *Message, signed *SignedProto) error {
 
 		// state data validation in proofs
 		if mProof.State != nil {
-			if !c.stateValidate(mProof.State) {
-				return ErrSelectProofStateValidation
+			if err := c.validateState(mProof.State, ErrSelectProofStateValidation); err != nil {
+				return err
 			}
 		}
 
@@ -740,8 +805,8 @@ func (c *Consensus) verifyCommitMessage(m *Message) error {
 	}
 
 	// state data validation
-	if !c.stateValidate(m.State) {
-		return ErrCommitStateValidation
+	if err := c.validateState(m.State, ErrCommitStateValidation); err != nil {
+		return err
 	}
 
 	// check height
@@ -765,6 +830,9 @@ func (c *Consensus) verifyCommitMessage(m *Message) error {
 // ValidateDecideMessage validates a <decide> message for non-participants,
 // the consensus core must be correctly initialized to validate.
 // the targetState is to compare the target state enclosed in decide message
+//
+// NOTE: states are validated synchronously with StateValidate even in async
+// validation mode, as callers have no chance to wait for the verdicts.
 func (c *Consensus) ValidateDecideMessage(bts []byte, targetState []byte) error {
 	signed, err := DecodeSignedMessage(bts)
 	if err != nil {
@@ -815,7 +883,7 @@ func (c *Consensus) validateDecideMessage(signed *SignedProto, targetState []byt
 
 	// verify decide message
 	if m.Type == MessageType_Decide {
-		err := c.verifyDecideMessage(m, signed)
+		err := c.verifyDecide(m, signed, c.validateStateSync)
 		if err != nil {
 			return err
 		}
@@ -827,28 +895,41 @@ func (c *Consensus) validateDecideMessage(signed *SignedProto, targetState []byt
 // verifyDecideMessage verifies proofs from <decide> message, which MUST
 // contain at least 2t+1 individual <commit> messages to B'.
 func (c *Consensus) verifyDecideMessage(m *Message, signed *SignedProto) error {
+	return c.verifyDecide(m, signed, c.validateState)
+}
+
+// verifyDecide verifies a <decide> message with the state validation function,
+// the height and the signatures are checked before validating states, so
+// stale or forged messages never start state validation.
+func (c *Consensus) verifyDecide(m *Message, signed *SignedProto, validate func(State, error) error) error {
 	// a <decide> message from leader MUST include data along with the message
 	if m.State == nil {
 		return ErrDecideEmptyState
 	}
 
-	// state data validation
-	if !c.stateValidate(m.State) {
-		return ErrDecideStateValidation
-	}
-
 	// check height
 	if m.Height <= c.latestHeight {
 		return ErrDecideHeightLower
 	}
 
-	// make sure this message has been signed by the leader
+	return c.verifyDecideProof(m, signed, validate)
+}
+
+// verifyDecideProof verifies the signer and the <commit> proofs of a <decide>
+// message regardless of its height, the states are validated with the
+// validation function after all signatures have been verified.
+func (c *Consensus) verifyDecideProof(m *Message, signed *SignedProto, validate func(State, error) error) error {
+	// make sure this message has been signed by the leader, in fast path,
+	// participants sign their own <decide> certificate, which must hold
+	// 2t+1 matching <commit>s as checked below.
 	leaderKey := c.roundLeader(m.Round)
-	if c.pubKeyToIdentity(signed.PublicKey(c.curve)) != leaderKey {
+	signer := signed.PublicKey(c.curve)
+	if c.pubKeyToIdentity(signer) != leaderKey && !(c.enableFastPath && c.IsParticipant(signer)) {
 		return ErrDecideNotSignedByLeader
 	}
 
 	commits := make(map[Identity]State)
+	var proofStates []State
 	for _, proof := range m.Proof {
 		mProof, err := c.verifyMessage(proof)
 		if err != nil {
@@ -870,18 +951,8 @@ func (c *Consensus) verifyDecideMessage(m *Message, signed *SignedProto) error {
 			return ErrDecideProofRoundMismatch
 		}
 
-		if !c.stateValidate(mProof.State) {
-			return ErrDecideProofStateValidation
-		}
-
-		// state data validation in proofs
-		if mProof.State != nil {
-			if !c.stateValidate(mProof.State) {
-				return ErrSelectProofStateValidation
-			}
-		}
-
 		commits[c.pubKeyToIdentity(proof.PublicKey(c.curve))] = mProof.State
+		proofStates = append(proofStates, mProof.State)
 	}
 
 	// count proofs to m.State
@@ -898,6 +969,18 @@ func (c *Consensus) verifyDecideMessage(m *Message, signed *SignedProto) error {
 	if numValidateProofs < 2*c.t()+1 {
 		return ErrDecideProofInsufficient
 	}
+
+	// state data validation
+	if err := validate(m.State, ErrDecideStateValidation); err != nil {
+		return err
+	}
+
+	// state data validation in proofs
+	for _, s := range proofStates {
+		if err := validate(s, ErrDecideProofStateValidation); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -917,6 +1000,11 @@ func (c *Consensus) broadcastRoundChange() {
 	if data == nil {
 		// if there's none locked data, we pick the maximum unconfirmed data to propose
 		data = c.maximalUnconfirmed()
+		// if still null, ask the provider for a state
+		if data == nil && c.proposalProvider != nil {
+			c.Propose(c.proposalProvider(c.latestHeight + 1))
+			data = c.maximalUnconfirmed()
+		}
 		// if still null, return
 		if data == nil {
 			return
@@ -970,8 +1058,8 @@ func (c *Consensus) broadcastSelect() {
 	//log.Println("broadcast:<select>", m.State)
 }
 
-// broadcastDecide will broadcast a <decide> message by the leader,
-// from current round with <commit> proofs.
+// broadcastDecide will broadcast a <decide> message by the leader, or by
+// participants in fast path, from current round with <commit> proofs.
 func (c *Consensus) broadcastDecide() *SignedProto {
 	var m Message
 	m.Type = MessageType_Decide
@@ -1024,6 +1112,7 @@ func (c *Consensus) broadcast(m *Message) *SignedProto {
 	// sign
 	sp := new(SignedProto)
 	sp.Version = ProtocolVersion
+	sp.ChainID = c.chainID
 	sp.Sign(m, c.privateKey)
 
 	// message callback
@@ -1051,6 +1140,7 @@ func (c *Consensus) sendTo(m *Message, leader Identity) {
 	// sign
 	sp := new(SignedProto)
 	sp.Version = ProtocolVersion
+	sp.ChainID = c.chainID
 	sp.Sign(m, c.privateKey)
 
 	// message callback
@@ -1142,7 +1232,12 @@ func (c *Consensus) lockRelease() {
 // switchRound sets currentRound to the given idx, and creates new a consensusRound
 // if it's not been initialized.
 // and all lower rounds will be cleared while switching.
-func (c *Consensus) switchRound(round uint64) { c.currentRound = c.getRound(round, true) }
+func (c *Consensus) switchRound(round uint64) {
+	c.currentRound = c.getRound(round, true)
+	if n := len(c.roundsEntered); n == 0 || c.roundsEntered[n-1] != round {
+		c.roundsEntered = append(c.roundsEntered, round)
+	}
+}
 
 // roundLeader returns leader's identity for a given round
 func (c *Consensus) roundLeader(round uint64) Identity {
@@ -1156,6 +1251,12 @@ func (c *Consensus) roundLeader(round uint64) Identity {
 // heightSync changes current height to the given height with state
 // resets all fields to this new height.
 func (c *Consensus) heightSync(height uint64, round uint64, s State, now time.Time) {
+	// summarize participation before rounds being cleared
+	c.latestReport = c.participationReport(height, round, s)
+	if c.participationCallback != nil {
+		c.participationCallback(c.latestReport)
+	}
+
 	c.latestHeight = height // set height
 	c.latestRound = round   // set round
 	c.latestState = s       // set state
@@ -1165,8 +1266,89 @@ func (c *Consensus) heightSync(height uint64, round uint64, s State, now time.Ti
 	c.rounds.Init()              // clean all round
 	c.locks = nil                // clean locks
 	c.unconfirmed = nil          // clean all unconfirmed states from previous heights
+	c.unconfirmedIndex = nil     // clean unconfirmed index
+	c.resetValidation()          // clean verdicts & parked messages from previous heights
+	c.roundsEntered = nil        // clean rounds entered at previous heights
 	c.switchRound(0)             // start new round at new height
 	c.currentRound.Stage = stageRoundChanging
+	c.replayFutureMessages() // re-process messages buffered for this height
+
+	if c.decideCallback != nil {
+		c.decideCallback(height, round, s, c.latestProof)
+	}
+
+	// receipts for client requests decided
+	c.issueReceipts(height, s, now)
+}
+
+// bufferFutureMessage buffers a verified message for a future height(within
+// futureHeights), returns true if the message belongs to a future height
+// and has been handled.
+func (c *Consensus) bufferFutureMessage(signed *SignedProto, m *Message, bts []byte) bool {
+	if c.futureHeights == 0 {
+		return false
+	}
+
+	// only messages bound to a height will be buffered, <decide> messages
+	// for higher heights are accepted directly.
+	switch m.Type {
+	case MessageType_RoundChange, MessageType_Lock, MessageType_Select, MessageType_LockRelease, MessageType_Commit:
+	default:
+		return false
+	}
+
+	if m.Height <= c.latestHeight+1 || m.Height > c.latestHeight+1+c.futureHeights {
+		return false
+	}
+
+	// the buffer is bounded for each participant to prevent OOM attack,
+	// messages will be dropped when exceeded.
+	id := c.pubKeyToIdentity(signed.PublicKey(c.curve))
+	if c.futureCounts[id] >= MaxFutureMessages {
+		return true
+	}
+
+	// ignore duplicated messages
+	for k := range c.futureMessages {
+		if c.futureMessages[k].Identity == id && bytes.Equal(c.futureMessages[k].Raw, bts) {
+			return true
+		}
+	}
+
+	raw := make([]byte, len(bts))
+	copy(raw, bts)
+	c.futureMessages = append(c.futureMessages, futureMessage{Identity: id, Height: m.Height, Raw: raw})
+	c.futureCounts[id]++
+	return true
+}
+
+// replayFutureMessages moves the buffered messages for the next height to
+// loopback, and removes the messages of lower heights.
+func (c *Consensus) replayFutureMessages() {
+	// in-place deletion
+	o := 0
+	for i := 0; i < len(c.futureMessages); i++ {
+		msg := c.futureMessages[i]
+		if msg.Height > c.latestHeight+1 {
+			c.futureMessages[o] = msg
+			o++
+			continue
+		}
+
+		if msg.Height == c.latestHeight+1 {
+			c.loopback = append(c.loopback, msg.Raw)
+		}
+
+		c.futureCounts[msg.Identity]--
+		if c.futureCounts[msg.Identity] == 0 {
+			delete(c.futureCounts, msg.Identity)
+		}
+	}
+
+	for i := o; i < len(c.futureMessages); i++ {
+		c.futureMessages[i] = futureMessage{} // set to nil to avoid memory leak
+	}
+	c.futureMessages = c.futureMessages[:o]
 }
 
 // t calculates (n-1)/3
@@ -1180,16 +1362,21 @@ func (c *Consensus) Propose(s State) {
 	}
 
 	sHash := c.stateHash(s)
-	for k := range c.unconfirmed {
-		if c.stateHash(c.unconfirmed[k]) == sHash {
-			return
-		}
+	if c.unconfirmedIndex[sHash] {
+		return
+	}
+	if c.unconfirmedIndex == nil {
+		c.unconfirmedIndex = make(map[StateHash]bool)
 	}
 	c.unconfirmed = append(c.unconfirmed, s)
+	c.unconfirmedIndex[sHash] = true
 }
 
 // ReceiveMessage processes incoming consensus messages, and returns error
 // if message cannot be processed for some reason.
+//
+// In async validation mode, ErrStateValidationPending will be returned if the
+// message has been parked awaiting verdicts of its states.
 func (c *Consensus) ReceiveMessage(bts []byte, now time.Time) (err error) {
 	// messages broadcasted to myself may be queued recursively, and
 	// we only process these messages in defer to avoid side effects
@@ -1203,10 +1390,22 @@ func (c *Consensus) ReceiveMessage(bts []byte, now time.Time) (err error) {
 		}
 	}()
 
+	// verdicts may have been delivered since last call
+	c.processVerdicts(now)
 	return c.receiveMessage(bts, now)
 }
 
+// receiveMessage processes a message, and parks it if the verdicts of its
+// states are not yet known in async validation mode.
 func (c *Consensus) receiveMessage(bts []byte, now time.Time) error {
+	err := c.processMessage(bts, now)
+	if err == ErrStateValidationPending {
+		c.parkMessage(bts)
+	}
+	return err
+}
+
+func (c *Consensus) processMessage(bts []byte, now time.Time) error {
 	// unmarshal signed message
 	signed := new(SignedProto)
 	err := proto.Unmarshal(bts, signed)
@@ -1232,6 +1431,14 @@ func (c *Consensus) receiveMessage(bts []byte, now time.Time) error {
 		}
 	}
 
+	// messages for future heights will be processed later
+	if c.bufferFutureMessage(signed, m, bts) {
+		return nil
+	}
+
+	// validate all states at once in async validation mode
+	c.prevalidate(m)
+
 	// message switch
 	switch m.Type {
 	case MessageType_Nop:
@@ -1389,6 +1596,13 @@ func (c *Consensus) receiveMessage(bts []byte, now time.Time) error {
 			c.locks = append(c.locks, messageTuple{StateHash: mHash, Message: m, Signed: signed})
 		}
 
+		// record what the leader has locked in this round, <commit> messages
+		// are collected against this state in fast path.
+		if c.enableFastPath && c.currentRound.LockedState == nil {
+			c.currentRound.LockedState = m.State
+			c.currentRound.LockedStateHash = c.stateHash(m.State)
+		}
+
 		// for any incoming <lock,h,r,B'> message with r=r', sendCommit will send
 		// <commit,h,r',B'> once.
 		c.sendCommit(m)
@@ -1426,9 +1640,10 @@ func (c *Consensus) receiveMessage(bts []byte, now time.Time) error {
 
 	case MessageType_Commit:
 		// leader process commits message from all participants,
-		// check to see if I'm the leader of this round to process this message.
+		// check to see if I'm the leader of this round to process this message,
+		// in fast path, all participants process commits.
 		leaderKey := c.roundLeader(m.Round)
-		if leaderKey == c.identity {
+		if leaderKey == c.identity || c.enableFastPath {
 			// verify commit message.
 			// NOTE: leader only accept commits for current height & round.
 			err := c.verifyCommitMessage(m)
@@ -1450,11 +1665,19 @@ func (c *Consensus) receiveMessage(bts []byte, now time.Time) error {
 						log.Println("State:", State(c.currentRound.LockedState).hash())
 					*/
 
-					// broadcast decide will return what it has sent
+					// broadcast decide will return what it has sent, in fast
+					// path, non-leaders broadcast the certificate signed by
+					// themselves for participants who have missed the <commit>
+					// messages, as the leader may have moved to next round
+					// before collecting them.
 					c.latestProof = c.broadcastDecide()
 					c.heightSync(c.latestHeight+1, c.currentRound.RoundNumber, c.currentRound.LockedState, now)
-					// leader should wait for 1 more latency
-					c.rcTimeout = now.Add(c.roundchangeDuration(0) + c.latency)
+					// non-leader starts waiting for rcTimeout, and leader
+					// should wait for 1 more latency
+					c.rcTimeout = now.Add(c.roundchangeDuration(0))
+					if leaderKey == c.identity {
+						c.rcTimeout = c.rcTimeout.Add(c.latency)
+					}
 					// broadcast <roundchange> at new height
 					c.broadcastRoundChange()
 				}
@@ -1510,6 +1733,9 @@ func (c *Consensus) Update(now time.Time) error {
 		}
 	}()
 
+	// process parked messages with new verdicts
+	c.processVerdicts(now)
+
 	// stage switch
 	switch c.currentRound.Stage {
 	case stageRoundChanging:
@@ -1593,6 +1819,34 @@ func (c *Consensus) Update(now time.Time) error {
 	return nil
 }
 
+// NextDeadline returns the earliest time point at which Update could change
+// state, callers can schedule Update at this time point instead of calling
+// it periodically. A zero time will be returned if Update can make progress
+// immediately.
+//
+// NOTE: the deadline may change after ReceiveMessage, Propose or Update,
+// callers should check again after calling them.
+func (c *Consensus) NextDeadline() time.Time {
+	switch c.currentRound.Stage {
+	case stageRoundChanging:
+		return c.rcTimeout
+	case stageLock:
+		// the leader can lock or select before collect timeout
+		if c.roundLeader(c.currentRound.RoundNumber) == c.identity {
+			if c.currentRound.MaxProposedCount >= 2*c.t()+1 ||
+				c.currentRound.NumRoundChanges() == len(c.participants) {
+				return time.Time{}
+			}
+		}
+		return c.lockTimeout
+	case stageCommit:
+		return c.commitTimeout
+	case stageLockRelease:
+		return c.lockReleaseTimeout
+	}
+	return time.Time{}
+}
+
 // CurrentState returns current state along with current height & round,
 // It's caller's responsibility to check if ReceiveMessage() has
 // created a new height.
@@ -1625,30 +1879,33 @@ func (c *Consensus) HasProposed(state State) bool {
 		}
 	}
 
-	for k := range c.unconfirmed {
-		if c.stateHash(c.unconfirmed[k]) == stateHash {
-			return true
-		}
+	if c.unconfirmedIndex[stateHash] {
+		return true
 	}
 
 	return false
 }
 
-// ReceiveMessage input to core incoming consensus messages, and returns error
-func (c *Consensus) SubmitRequest(bts []byte, now time.Time) (err error) {
-	// messages broadcasted to myself may be queued recursively, and
-	// we only process these messages in defer to avoid side effects
-	// while processing.
-	defer func() {
-		for len(c.loopback) > 0 {
-			bts := c.loopback[0]
-			c.loopback = c.loopback[1:]
-			// NOTE: message directed to myself ignores error.
-			_ = c.receiveMessage(bts, now)
-		}
-	}()
+// StateHash returns the hash which identifies a state
+func (c *Consensus) StateHash(s State) StateHash { return c.stateHash(s) }
 
-	return c.receiveMessage(bts, now)
+// ValidateState validates a state with Config.StateValidate synchronously
+func (c *Consensus) ValidateState(s State) bool { return c.stateValidate(s) }
+
+// ChainID returns the network identifier this consensus runs on, it's
+// immutable, so it's safe to be called concurrently.
+func (c *Consensus) ChainID() []byte { return c.chainID }
+
+// IsParticipant returns true if the public key belongs to a participant,
+// participants are immutable, so it's safe to be called concurrently.
+func (c *Consensus) IsParticipant(pubkey *ecdsa.PublicKey) bool {
+	coord := c.pubKeyToIdentity(pubkey)
+	for k := range c.participants {
+		if coord == c.participants[k] {
+			return true
+		}
+	}
+	return false
 }
 
 // Join adds a peer to consensus for message delivery, a peer is
@@ -134,6 +134,56 @@ func TestProposeMultipleRoundChanges(t *testing.T) {
 	assert.Equal(t, highest, lastOne.Message.Round)
 }
 
+func TestFutureMessages(t *testing.T) {
+	t.Log("buffer <roundchange> messages for future heights and replay them after height sync")
+	privateKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+	assert.Nil(t, err)
+
+	consensus := createConsensus(t, 0, 0, []*ecdsa.PublicKey{&privateKey.PublicKey})
+	consensus.futureHeights = 2
+	state := make([]byte, 1024)
+	_, err = io.ReadFull(rand.Reader, state)
+	assert.Nil(t, err)
+
+	// a message too far ahead will be rejected
+	_, sp, _ := createRoundChangeMessageSigner(t, 4, 0, state, privateKey)
+	bts, err := proto.Marshal(sp)
+	assert.Nil(t, err)
+	err = consensus.ReceiveMessage(bts, time.Now())
+	assert.Equal(t, ErrRoundChangeHeightMismatch, err)
+	assert.Equal(t, 0, len(consensus.futureMessages))
+
+	// messages within the window will be buffered, up to MaxFutureMessages
+	var first []byte
+	for i := 0; i < MaxFutureMessages+10; i++ {
+		_, sp, _ := createRoundChangeMessageSigner(t, 2, uint64(i), state, privateKey)
+		bts, err := proto.Marshal(sp)
+		assert.Nil(t, err)
+		if i == 0 {
+			first = bts
+		}
+		err = consensus.ReceiveMessage(bts, time.Now())
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, MaxFutureMessages, len(consensus.futureMessages))
+
+	// duplicated messages will not be buffered twice
+	consensus.futureCounts = make(map[Identity]int)
+	consensus.futureMessages = nil
+	for i := 0; i < 10; i++ {
+		err = consensus.ReceiveMessage(first, time.Now())
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 1, len(consensus.futureMessages))
+
+	// move to height 1, the message should be replayed
+	consensus.heightSync(1, 0, state, time.Now())
+	assert.Equal(t, 0, len(consensus.futureMessages))
+	assert.Equal(t, 0, len(consensus.futureCounts))
+	_ = consensus.Update(time.Now())
+	assert.NotEqual(t, -1, consensus.currentRound.FindRoundChange(sp.X, sp.Y))
+}
+
 func TestMultipleCommits(t *testing.T) {
 	privateKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
 	assert.Nil(t, err)
@@ -174,6 +224,331 @@ func TestMultipleCommits(t *testing.T) {
 	assert.Equal(t, 1, count)
 }
 
+func TestFastPathDecide(t *testing.T) {
+	testFastPathDecide(t, true)
+}
+
+func TestFastPathDisabled(t *testing.T) {
+	testFastPathDecide(t, false)
+}
+
+func testFastPathDecide(t *testing.T, fastPath bool) {
+	t.Log("participants decide locally on 2t+1 <commit> messages in fast path")
+	const quorum = 20
+	var keys []*ecdsa.PrivateKey
+	var pubkeys []*ecdsa.PublicKey
+	for i := 0; i < quorum-1; i++ {
+		privateKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+		assert.Nil(t, err)
+		keys = append(keys, privateKey)
+		pubkeys = append(pubkeys, &privateKey.PublicKey)
+	}
+
+	consensus := createConsensus(t, 0, 0, pubkeys)
+	consensus.enableFastPath = fastPath
+	// the leader is someone else
+	consensus.SetLeader(pubkeys[0])
+
+	state := make([]byte, 1024)
+	_, err := io.ReadFull(rand.Reader, state)
+	assert.Nil(t, err)
+	consensus.currentRound.Stage = stageCommit
+	consensus.currentRound.LockedState = state
+	consensus.currentRound.LockedStateHash = consensus.stateHash(state)
+
+	valid := 2*consensus.t() + 1
+	for i := 0; i < valid; i++ {
+		_, signed, _ := createCommitMessageSigner(t, 1, 0, state, keys[i])
+		bts, err := proto.Marshal(signed)
+		assert.Nil(t, err)
+		err = consensus.ReceiveMessage(bts, time.Now())
+		assert.Nil(t, err)
+	}
+
+	height, _, decided := consensus.CurrentState()
+	if !fastPath {
+		// non-leader waits for <decide> from the leader
+		assert.Equal(t, uint64(0), height)
+		assert.Nil(t, consensus.CurrentProof())
+		return
+	}
+
+	assert.Equal(t, uint64(1), height)
+	assert.Equal(t, State(state), decided)
+
+	// the certificate is signed by myself and can be verified by others in fast path
+	validator := createConsensus(t, 0, 0, append(pubkeys, &consensus.privateKey.PublicKey))
+	validator.enableFastPath = true
+	proof, err := proto.Marshal(consensus.CurrentProof())
+	assert.Nil(t, err)
+	assert.Nil(t, validator.ValidateDecideMessage(proof, state))
+
+	// but not in normal mode
+	validator.enableFastPath = false
+	assert.Equal(t, ErrDecideNotSignedByLeader, validator.ValidateDecideMessage(proof, state))
+}
+
+func TestParticipationReport(t *testing.T) {
+	m, sp, privateKey, proofKeys := createDecideMessage(t, 20, 10, 3, 10, 3)
+	consensus := createConsensus(t, 9, 1, proofKeys)
+	consensus.SetLeader(&privateKey.PublicKey)
+	assert.Nil(t, consensus.CurrentParticipationReport())
+	// round 2 is skipped through round change
+	consensus.switchRound(3)
+
+	var reported *ParticipationReport
+	consensus.participationCallback = func(report *ParticipationReport) { reported = report }
+
+	// a <roundchange> from the leader at this height
+	_, rc, _ := createRoundChangeMessageSigner(t, 10, 3, m.State, privateKey)
+	bts, err := proto.Marshal(rc)
+	assert.Nil(t, err)
+	assert.Nil(t, consensus.ReceiveMessage(bts, time.Now()))
+
+	bts, err = proto.Marshal(sp)
+	assert.Nil(t, err)
+	assert.Nil(t, consensus.ReceiveMessage(bts, time.Now()))
+
+	report := consensus.CurrentParticipationReport()
+	assert.NotNil(t, report)
+	assert.Equal(t, reported, report)
+	assert.Equal(t, m.Height, report.Height)
+	assert.Equal(t, m.Round, report.Round)
+
+	valid := 2*consensus.t() + 1
+	assert.Equal(t, valid, len(report.Committers))
+	assert.Equal(t, consensus.numIdentities-valid, len(report.Missing))
+	for i := 0; i < valid; i++ {
+		assert.Contains(t, report.Committers, DefaultPubKeyToIdentity(proofKeys[i]))
+	}
+	assert.Contains(t, report.Missing, consensus.identity)
+
+	// the leaders of round 0, 1 have failed
+	leader := DefaultPubKeyToIdentity(&privateKey.PublicKey)
+	assert.Equal(t, []RoundLeader{{0, leader}, {1, leader}}, report.FailedLeaders)
+	assert.False(t, report.Partial)
+
+	assert.Equal(t, []Identity{leader}, report.RoundChangers)
+}
+
+func TestParticipationReportPartial(t *testing.T) {
+	m, sp, privateKey, proofKeys := createDecideMessage(t, 20, 12, 3, 12, 3)
+	consensus := createConsensus(t, 9, 1, proofKeys)
+	consensus.SetLeader(&privateKey.PublicKey)
+
+	// jump from height 9 to 12
+	bts, err := proto.Marshal(sp)
+	assert.Nil(t, err)
+	assert.Nil(t, consensus.ReceiveMessage(bts, time.Now()))
+
+	report := consensus.CurrentParticipationReport()
+	assert.NotNil(t, report)
+	assert.Equal(t, m.Height, report.Height)
+	assert.True(t, report.Partial)
+	assert.Equal(t, 2*consensus.t()+1, len(report.Committers))
+	assert.Empty(t, report.FailedLeaders)
+	assert.Empty(t, report.RoundChangers)
+}
+
+func TestNextDeadline(t *testing.T) {
+	consensus := createConsensus(t, 0, 0, nil)
+	now := time.Now()
+	consensus.rcTimeout = now.Add(time.Second)
+	consensus.lockTimeout = now.Add(2 * time.Second)
+	consensus.commitTimeout = now.Add(3 * time.Second)
+	consensus.lockReleaseTimeout = now.Add(4 * time.Second)
+
+	consensus.currentRound.Stage = stageRoundChanging
+	assert.Equal(t, consensus.rcTimeout, consensus.NextDeadline())
+	consensus.currentRound.Stage = stageCommit
+	assert.Equal(t, consensus.commitTimeout, consensus.NextDeadline())
+	consensus.currentRound.Stage = stageLockRelease
+	assert.Equal(t, consensus.lockReleaseTimeout, consensus.NextDeadline())
+
+	// non-leader waits for lock timeout
+	privateKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	consensus.currentRound.Stage = stageLock
+	consensus.SetLeader(&privateKey.PublicKey)
+	consensus.currentRound.MaxProposedCount = 2*consensus.t() + 1
+	assert.Equal(t, consensus.lockTimeout, consensus.NextDeadline())
+
+	// leader can lock immediately with 2t+1 <roundchange> to the same state
+	consensus.SetLeader(&consensus.privateKey.PublicKey)
+	assert.True(t, consensus.NextDeadline().IsZero())
+	consensus.currentRound.MaxProposedCount = 0
+	assert.Equal(t, consensus.lockTimeout, consensus.NextDeadline())
+}
+
+func TestProposalProvider(t *testing.T) {
+	consensus := createConsensus(t, 10, 0, nil)
+	var heights []uint64
+	var provided State
+	consensus.proposalProvider = func(height uint64) State {
+		heights = append(heights, height)
+		return provided
+	}
+
+	// nothing to propose
+	consensus.broadcastRoundChange()
+	assert.Equal(t, []uint64{11}, heights)
+	assert.Equal(t, 0, len(consensus.unconfirmed))
+
+	// the provided state will be proposed, and sent in <roundchange>
+	provided = State("provided")
+	consensus.broadcastRoundChange()
+	assert.Equal(t, []uint64{11, 11}, heights)
+	assert.True(t, consensus.HasProposed(provided))
+	assert.True(t, consensus.currentRound.RoundChangeSent)
+
+	// the provider won't be called with unconfirmed states
+	consensus.broadcastRoundChange()
+	assert.Equal(t, []uint64{11, 11}, heights)
+}
+
+func TestAsyncValidation(t *testing.T) {
+	// all proofs carry the decided state
+	m, sp, privateKey, proofKeys := createDecideMessage(t, 1, 10, 3, 10, 3)
+	bts, err := proto.Marshal(sp)
+	assert.Nil(t, err)
+
+	for _, valid := range []bool{true, false} {
+		consensus := createConsensus(t, 9, 3, proofKeys)
+		consensus.SetLeader(&privateKey.PublicKey)
+
+		var calls int32
+		release := make(chan struct{})
+		consensus.asyncValidator = newAsyncValidator(func(State) bool {
+			atomic.AddInt32(&calls, 1)
+			<-release
+			return valid
+		}, 9)
+
+		// the message will be parked until the verdict is known
+		assert.Equal(t, ErrStateValidationPending, consensus.ReceiveMessage(bts, time.Now()))
+		assert.Equal(t, ErrStateValidationPending, consensus.ReceiveMessage(bts, time.Now()))
+		assert.Equal(t, 2, consensus.NumParkedMessages())
+		height, _, _ := consensus.CurrentState()
+		assert.Equal(t, uint64(9), height)
+
+		close(release)
+		<-consensus.ValidationNotify()
+		assert.Nil(t, consensus.Update(time.Now()))
+		assert.Equal(t, 0, consensus.NumParkedMessages())
+
+		// identical states are validated once
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+		height, _, state := consensus.CurrentState()
+		if valid {
+			assert.Equal(t, m.Height, height)
+			assert.Equal(t, State(m.State), state)
+		} else {
+			assert.Equal(t, uint64(9), height)
+			assert.Equal(t, ErrDecideStateValidation, consensus.ReceiveMessage(bts, time.Now()))
+		}
+	}
+}
+
+func TestAsyncValidationAllStates(t *testing.T) {
+	// 13 proofs carry the decided state, 7 proofs carry random states
+	m, sp, privateKey, proofKeys := createDecideMessage(t, 20, 10, 3, 10, 3)
+	bts, err := proto.Marshal(sp)
+	assert.Nil(t, err)
+
+	consensus := createConsensus(t, 9, 3, proofKeys)
+	consensus.SetLeader(&privateKey.PublicKey)
+	var calls int32
+	release := make(chan struct{})
+	consensus.asyncValidator = newAsyncValidator(func(State) bool {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return true
+	}, 9)
+
+	// all states are being validated at once
+	assert.Equal(t, ErrStateValidationPending, consensus.ReceiveMessage(bts, time.Now()))
+	assert.Equal(t, 8, len(consensus.asyncValidator.validating))
+
+	close(release)
+	deadline := time.After(5 * time.Second)
+	for consensus.NumParkedMessages() > 0 {
+		select {
+		case <-consensus.ValidationNotify():
+			assert.Nil(t, consensus.Update(time.Now()))
+		case <-deadline:
+			t.Fatal("verdicts have not been delivered in time")
+		}
+	}
+
+	height, _, _ := consensus.CurrentState()
+	assert.Equal(t, m.Height, height)
+	assert.Equal(t, int32(8), atomic.LoadInt32(&calls))
+}
+
+func TestAsyncValidationBounded(t *testing.T) {
+	consensus := createConsensus(t, 9, 0, nil)
+	var calls int32
+	release := make(chan struct{})
+	consensus.asyncValidator = newAsyncValidator(func(State) bool {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return true
+	}, 9)
+	v := consensus.asyncValidator
+
+	// at most 2 states are validating with 1 participant
+	assert.Equal(t, 2, consensus.maxValidatingStates())
+	assert.Equal(t, ErrStateValidationPending, consensus.validateState(State{1}, nil))
+	assert.Equal(t, ErrStateValidationPending, consensus.validateState(State{2}, nil))
+	assert.Equal(t, ErrStateValidationLimit, consensus.validateState(State{3}, nil))
+	v.mu.Lock()
+	assert.LessOrEqual(t, v.workers, v.maxWorkers)
+	v.mu.Unlock()
+
+	// verdicts started at previous heights are dropped
+	for atomic.LoadInt32(&calls) == 0 {
+		<-time.After(time.Millisecond)
+	}
+	consensus.latestHeight = 10
+	consensus.resetValidation()
+	close(release)
+	select {
+	case <-consensus.ValidationNotify():
+	case <-time.After(5 * time.Second):
+		t.Fatal("verdict has not been delivered in time")
+	}
+	assert.Nil(t, consensus.Update(time.Now()))
+	assert.Equal(t, 0, len(v.verdicts))
+	assert.Equal(t, ErrStateValidationPending, consensus.validateState(State{1}, nil))
+}
+
+func TestAsyncValidationDecide(t *testing.T) {
+	_, sp, privateKey, proofKeys := createDecideMessage(t, 1, 10, 3, 10, 3)
+	bts, err := proto.Marshal(sp)
+	assert.Nil(t, err)
+
+	consensus := createConsensus(t, 10, 3, proofKeys)
+	consensus.SetLeader(&privateKey.PublicKey)
+	consensus.asyncValidator = newAsyncValidator(func(State) bool { return true }, 10)
+
+	// stale <decide> messages start no validation
+	assert.Equal(t, ErrDecideHeightLower, consensus.ReceiveMessage(bts, time.Now()))
+	assert.Equal(t, 0, len(consensus.asyncValidator.validating))
+	assert.Equal(t, 0, consensus.NumParkedMessages())
+
+	// light clients validate synchronously
+	consensus.latestHeight = 9
+	valid := true
+	consensus.stateValidate = func(State) bool { return valid }
+	m, err := DecodeMessage(sp.Message)
+	assert.Nil(t, err)
+	assert.Nil(t, consensus.ValidateDecideMessage(bts, m.State))
+	valid = false
+	assert.Equal(t, ErrDecideStateValidation, consensus.ValidateDecideMessage(bts, m.State))
+	assert.Equal(t, 0, len(consensus.asyncValidator.validating))
+}
+
 func TestMaximalLocked(t *testing.T) {
 	consensus := createConsensus(t, 0, 0, nil)
 
@@ -390,6 +765,7 @@ type testParam struct {
 	stopHeight      int
 	latency         time.Duration
 	expectedLatency time.Duration
+	fastPath        bool
 }
 
 func TestConsensusTableFormat(t *testing.T) {
@@ -405,6 +781,20 @@ func TestConsensusTableFormat(t *testing.T) {
 	beginTest(t, params)
 }
 
+func TestConsensusFastPath20Participants(t *testing.T) {
+	var params = []testParam{
+		{
+			numPeers:        20,
+			numParticipants: 20,
+			stopHeight:      5,
+			latency:         100 * time.Millisecond,
+			expectedLatency: 100 * time.Millisecond,
+			fastPath:        true,
+		},
+	}
+	beginTest(t, params)
+}
+
 func TestConsensusFull20Participants(t *testing.T) {
 	var params = []testParam{
 		{
@@ -994,6 +1384,7 @@ func testConsensus(t *testing.T, param *testParam) []string {
 			config.CurrentHeight = currentHeight
 			config.PrivateKey = participants[i] // randomized participants
 			config.Participants = coords        // keep all coords
+			config.EnableFastPath = param.fastPath
 
 			// should replace with real function
 			config.StateCompare = func(a State, b State) int { return bytes.Compare(a, b) }
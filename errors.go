@@ -12,6 +12,7 @@ var (
 	ErrConfigPrivateKey         = errors.New("Config.PrivateKey has not set")
 	ErrConfigParticipants       = errors.New("Config.Participants must contain at least 4 participants")
 	ErrConfigPubKeyToCoordinate = errors.New("Config.must contain at least 4 participants")
+	ErrConfigFastPath           = errors.New("Config.EnableFastPath cannot be used with Config.EnableCommitUnicast")
 
 	// common errors related to every message
 	ErrMessageVersion            = errors.New("the message has different version")
@@ -20,6 +21,7 @@ var (
 	ErrMessageUnknownMessageType = errors.New("unrecognized message type")
 	ErrMessageSignature          = errors.New("cannot verify the signature of this message")
 	ErrMessageUnknownParticipant = errors.New("the message is from unknown partcipants")
+	ErrMessageChainID            = errors.New("the message has been signed for another chain")
 
 	// <roundchange> related
 	ErrRoundChangeHeightMismatch  = errors.New("the <roundchange> message has another height than expected")
@@ -79,4 +81,29 @@ var (
 
 	// <decide> verification
 	ErrMismatchedTargetState = errors.New("the state in <decide> message does not match the provided target state")
+
+	// beacon related
+	ErrBeaconNotDecideMessage = errors.New("the beacon can only be derived from a <decide> message")
+	ErrBeaconMismatch         = errors.New("the beacon does not match the one derived from the <decide> message")
+	ErrBeaconCommits          = errors.New("the <decide> message has less than 2t+1 <commit>s to derive the beacon")
+	ErrBeaconFastPath         = errors.New("the beacon is not supported with fast path")
+
+	// async validation related
+	ErrStateValidationPending = errors.New("the state is being validated asynchronously")
+	ErrStateValidationLimit   = errors.New("too many states are being validated at this height")
+
+	// client request related
+	ErrRequestChainID    = errors.New("the client request has been signed for another chain")
+	ErrRequestSignature  = errors.New("the client request has an invalid signature")
+	ErrRequestDuplicated = errors.New("the client request has already been submitted")
+	ErrRequestPoolFull   = errors.New("too many pending client requests")
+	ErrRequestExpired    = errors.New("the client request has expired")
+	ErrRequestLifetime   = errors.New("the client request expires too far ahead")
+	ErrReceiptProof      = errors.New("the receipt has no <decide> proof")
+	ErrReceiptHeight     = errors.New("the receipt height does not match the <decide> proof")
+	ErrReceiptRequest    = errors.New("the request is not included in the decided state")
+
+	// application related
+	ErrAppHeightGap   = errors.New("the decided height is not next to the last applied height")
+	ErrAppProofHeight = errors.New("the height of the <decide> proof mismatches the state to apply")
 )
@@ -36,6 +36,11 @@ type IPCPeer struct {
 	minLatency   time.Duration
 	maxLatency   time.Duration
 	totalLatency time.Duration
+
+	// scheduled consensus update
+	updateScheduled bool
+	nextUpdate      time.Time
+	updateSeq       uint64
 }
 
 // NewIPCPeer creates IPC based peer with latency, latency is distributed with
@@ -46,6 +51,9 @@ func NewIPCPeer(c *Consensus, latency time.Duration) *IPCPeer {
 	p.latency = latency
 	p.die = make(chan struct{})
 	p.minLatency = math.MaxInt64
+	if c != nil && c.ValidationNotify() != nil {
+		go p.validationLoop(c.ValidationNotify())
+	}
 	return p
 }
 
@@ -112,6 +120,8 @@ func (p *IPCPeer) Send(msg []byte) error {
 		if err != nil {
 			//		log.Println(err)
 		}
+		// the message may change the deadline
+		p.scheduleUpdate()
 	}
 
 	timer.SystemTimedSched.Put(txDelay, time.Now().Add(delay))
@@ -123,17 +133,60 @@ func (p *IPCPeer) delay() time.Duration {
 	return time.Duration(0.1*rand.NormFloat64()*float64(p.latency)) + p.latency
 }
 
-// Update will call itself perodically
+// Update will call consensus update, and schedule itself at the next
+// deadline of consensus
 func (p *IPCPeer) Update() {
 	p.Lock()
 	defer p.Unlock()
+	p.update()
+}
 
+// update calls consensus update and reschedules, the lock must be held.
+func (p *IPCPeer) update() {
 	select {
 	case <-p.die:
 	default:
 		// call consensus update
 		_ = p.c.Update(time.Now())
-		timer.SystemTimedSched.Put(p.Update, time.Now().Add(20*time.Millisecond))
+		p.scheduleUpdate()
+	}
+}
+
+// scheduleUpdate schedules an update at the next deadline of consensus, if
+// it's earlier than the one scheduled, the lock must be held.
+func (p *IPCPeer) scheduleUpdate() {
+	deadline := p.c.NextDeadline()
+	if p.updateScheduled && !deadline.Before(p.nextUpdate) {
+		return
+	}
+
+	p.updateSeq++
+	p.updateScheduled = true
+	p.nextUpdate = deadline
+
+	seq := p.updateSeq
+	timer.SystemTimedSched.Put(func() {
+		p.Lock()
+		defer p.Unlock()
+		// superseded by an earlier update
+		if !p.updateScheduled || seq != p.updateSeq {
+			return
+		}
+		p.updateScheduled = false
+		p.update()
+	}, deadline)
+}
+
+// validationLoop updates consensus when new verdicts are ready in async
+// validation mode
+func (p *IPCPeer) validationLoop(notify <-chan struct{}) {
+	for {
+		select {
+		case <-notify:
+			p.Update()
+		case <-p.die:
+			return
+		}
 	}
 }
 
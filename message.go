@@ -93,7 +93,10 @@ func DefaultPubKeyToIdentity(pubkey *ecdsa.PublicKey) (ret Identity) {
 }
 
 // Hash concats and hash as follows:
-// blake2b(signPrefix + version + pubkey.X + pubkey.Y+len_32bit(msg) + message)
+// blake2b(signPrefix + version + [len_32bit(chainid) + chainid] + pubkey.X + pubkey.Y+len_32bit(msg) + message)
+//
+// the chain id is only written when it's not empty, so messages signed without
+// a chain id keep the same hash.
 func (sp *SignedProto) Hash() []byte {
 	hash, err := blake2b.New256(nil)
 	if err != nil {
@@ -111,6 +114,19 @@ func (sp *SignedProto) Hash() []byte {
 		panic(err)
 	}
 
+	// write chain id
+	if len(sp.ChainID) > 0 {
+		err = binary.Write(hash, binary.LittleEndian, uint32(len(sp.ChainID)))
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = hash.Write(sp.ChainID)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	// write X & Y
 	_, err = hash.Write(sp.X[:])
 	if err != nil {
@@ -137,7 +153,8 @@ func (sp *SignedProto) Hash() []byte {
 	return hash.Sum(nil)
 }
 
-// Sign the message with a private key
+// Sign the message with a private key, the ChainID field(if any) should be
+// set before signing.
 func (sp *SignedProto) Sign(m *Message, privateKey *ecdsa.PrivateKey) {
 	bts, err := proto.Marshal(m)
 	if err != nil {
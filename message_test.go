@@ -346,6 +346,42 @@ func TestVerifyMessageUnknownParticipant(t *testing.T) {
 	assert.Equal(t, ErrMessageUnknownParticipant, err)
 }
 
+func TestVerifyMessageChainID(t *testing.T) {
+	// signer
+	privateKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+	assert.Nil(t, err)
+
+	// create consensus on a chain
+	consensus := createConsensus(t, 0, 0, []*ecdsa.PublicKey{&privateKey.PublicKey})
+	consensus.chainID = []byte("mainnet")
+
+	// message signed without chain id
+	message := Message{}
+	sp := new(SignedProto)
+	sp.Sign(&message, privateKey)
+	_, err = consensus.verifyMessage(sp)
+	assert.Equal(t, ErrMessageChainID, err)
+
+	// message signed for another chain
+	sp = new(SignedProto)
+	sp.ChainID = []byte("testnet")
+	sp.Sign(&message, privateKey)
+	_, err = consensus.verifyMessage(sp)
+	assert.Equal(t, ErrMessageChainID, err)
+
+	// replay the signature with the chain id replaced
+	sp.ChainID = []byte("mainnet")
+	_, err = consensus.verifyMessage(sp)
+	assert.Equal(t, ErrMessageSignature, err)
+
+	// message signed for this chain
+	sp = new(SignedProto)
+	sp.ChainID = []byte("mainnet")
+	sp.Sign(&message, privateKey)
+	_, err = consensus.verifyMessage(sp)
+	assert.Nil(t, err)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 //
 // <roundchange> message related tests
@@ -0,0 +1,191 @@
+package replog
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/BDLS-bft/bdls"
+	"github.com/BDLS-bft/bdls/crypto/blake2b"
+)
+
+const (
+	// BatchPrefix is the domain separator for signing batches
+	BatchPrefix = "BDLS_REPLOG_BATCH"
+
+	// |X(32bytes)|Y(32bytes)|R(32bytes)|S(32bytes)|
+	batchHeaderSize = 4 * bdls.SizeAxis
+)
+
+// pendingEntry is an entry awaiting to be committed, identified by the
+// sequence number in the log which appended it, so an entry can be removed
+// from the pending queue exactly once it has been committed.
+type pendingEntry struct {
+	seq  uint64
+	data []byte
+}
+
+// batch is a batch of entries appended to the log of origin, signed by
+// origin so entries cannot be forged by other proposers.
+type batch struct {
+	origin  bdls.Identity // the public key(X, Y) of the log which appended the entries
+	r, s    *big.Int      // signature of origin
+	entries []pendingEntry
+}
+
+// encodeBatch encodes a batch into a state as follows:
+// |X(32 bytes)|Y(32 bytes)|R(32 bytes)|S(32 bytes)|count(uvarint)| { |seq(8 bytes)|len(uvarint)|data| } ...
+func encodeBatch(b *batch) bdls.State {
+	var buf bytes.Buffer
+	var varint [binary.MaxVarintLen64]byte
+	var seq [8]byte
+	var sig [2 * bdls.SizeAxis]byte
+
+	buf.Write(b.origin[:])
+	if b.r != nil && b.s != nil {
+		b.r.FillBytes(sig[:bdls.SizeAxis])
+		b.s.FillBytes(sig[bdls.SizeAxis:])
+	}
+	buf.Write(sig[:])
+
+	n := binary.PutUvarint(varint[:], uint64(len(b.entries)))
+	buf.Write(varint[:n])
+	for _, e := range b.entries {
+		binary.LittleEndian.PutUint64(seq[:], e.seq)
+		buf.Write(seq[:])
+
+		n := binary.PutUvarint(varint[:], uint64(len(e.data)))
+		buf.Write(varint[:n])
+		buf.Write(e.data)
+	}
+	return buf.Bytes()
+}
+
+// decodeBatch decodes a batch from a state, the signature is not verified.
+func decodeBatch(s bdls.State) (*batch, error) {
+	if len(s) < batchHeaderSize {
+		return nil, ErrCorruptBatch
+	}
+
+	b := new(batch)
+	copy(b.origin[:], s)
+	b.r = new(big.Int).SetBytes(s[2*bdls.SizeAxis : 3*bdls.SizeAxis])
+	b.s = new(big.Int).SetBytes(s[3*bdls.SizeAxis : batchHeaderSize])
+	s = s[batchHeaderSize:]
+
+	count, n := binary.Uvarint(s)
+	if n <= 0 {
+		return nil, ErrCorruptBatch
+	}
+	s = s[n:]
+
+	// every entry takes at least 9 bytes
+	if count > uint64(len(s))/9 {
+		return nil, ErrCorruptBatch
+	}
+
+	b.entries = make([]pendingEntry, 0, count)
+	for i := uint64(0); i < count; i++ {
+		if len(s) < 8 {
+			return nil, ErrCorruptBatch
+		}
+		var e pendingEntry
+		e.seq = binary.LittleEndian.Uint64(s[:8])
+		s = s[8:]
+
+		size, n := binary.Uvarint(s)
+		if n <= 0 || size > MaxEntrySize || size > uint64(len(s)-n) {
+			return nil, ErrCorruptBatch
+		}
+		s = s[n:]
+		e.data = s[:size:size]
+		s = s[size:]
+		b.entries = append(b.entries, e)
+	}
+
+	if len(s) != 0 {
+		return nil, ErrCorruptBatch
+	}
+	return b, nil
+}
+
+// hash returns the hash of the batch to sign, as follows:
+// blake2b(BatchPrefix + X + Y + count + entries)
+func (b *batch) hash() []byte {
+	unsigned := batch{origin: b.origin, entries: b.entries}
+	s := encodeBatch(&unsigned)
+
+	hash, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+	_, err = hash.Write([]byte(BatchPrefix))
+	if err != nil {
+		panic(err)
+	}
+	_, err = hash.Write(s[:2*bdls.SizeAxis])
+	if err != nil {
+		panic(err)
+	}
+	_, err = hash.Write(s[batchHeaderSize:])
+	if err != nil {
+		panic(err)
+	}
+	return hash.Sum(nil)
+}
+
+// sign signs the batch with the private key of origin
+func (b *batch) sign(privateKey *ecdsa.PrivateKey) {
+	b.origin = bdls.DefaultPubKeyToIdentity(&privateKey.PublicKey)
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, b.hash())
+	if err != nil {
+		panic(err)
+	}
+	b.r, b.s = r, s
+}
+
+// publicKey returns the public key of origin
+func (b *batch) publicKey(curve elliptic.Curve) *ecdsa.PublicKey {
+	pubkey := new(ecdsa.PublicKey)
+	pubkey.Curve = curve
+	pubkey.X = new(big.Int).SetBytes(b.origin[:bdls.SizeAxis])
+	pubkey.Y = new(big.Int).SetBytes(b.origin[bdls.SizeAxis:])
+	return pubkey
+}
+
+// verify verifies the signature of origin
+func (b *batch) verify(curve elliptic.Curve) bool {
+	pubkey := b.publicKey(curve)
+	if !curve.IsOnCurve(pubkey.X, pubkey.Y) {
+		return false
+	}
+	return ecdsa.Verify(pubkey, b.hash(), b.r, b.s)
+}
+
+// batchCount returns the number of entries in a batch, or 0 if malformed
+func batchCount(s bdls.State) uint64 {
+	if len(s) < batchHeaderSize {
+		return 0
+	}
+	count, n := binary.Uvarint(s[batchHeaderSize:])
+	if n <= 0 {
+		return 0
+	}
+	return count
+}
+
+// compareBatches is the StateCompare function for batches, a batch with more
+// entries is larger, and batches with the same number of entries are compared
+// in bytes.
+func compareBatches(a bdls.State, b bdls.State) int {
+	ca, cb := batchCount(a), batchCount(b)
+	if ca < cb {
+		return -1
+	} else if ca > cb {
+		return 1
+	}
+	return bytes.Compare(a, b)
+}
@@ -0,0 +1,11 @@
+// Package replog implements a replicated write-ahead log on top of BDLS
+// consensus, entries appended on any participant are batched into states,
+// and committed entries are persisted in a durable, ordered and gap-free log,
+// every entry appears in the log exactly once. Entries are batched and signed
+// by the participant which appended them, so they cannot be forged or
+// suppressed by other proposers.
+//
+// The log stops writing with bdls.ErrAppHeightGap if consensus jumps over some
+// heights, rather than leaving a gap, and resumes once the missing batches
+// have been fed to Log.CatchUp.
+package replog
@@ -0,0 +1,11 @@
+package replog
+
+import "errors"
+
+var (
+	ErrClosed        = errors.New("the log has been closed")
+	ErrCorruptRecord = errors.New("the log has a corrupt record")
+	ErrCorruptBatch  = errors.New("the batch of entries is malformed")
+	ErrHeightGap     = errors.New("the record is not next to the last height in log")
+	ErrEntryTooLarge = errors.New("the entry size exceeded maximum")
+)
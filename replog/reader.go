@@ -0,0 +1,51 @@
+package replog
+
+import "context"
+
+// Reader reads committed entries from a log in order, it supports tailing
+// by waiting for new entries to be committed.
+type Reader struct {
+	l       *Log
+	height  uint64  // next height to read
+	entries []Entry // entries of the last height read
+	index   int     // next entry in entries
+}
+
+// NewReader creates a reader starting from the first entry at the given
+// height, heights start from 1.
+func (l *Log) NewReader(height uint64) *Reader {
+	if height == 0 {
+		height = 1
+	}
+	return &Reader{l: l, height: height}
+}
+
+// Next returns the next committed entry, it blocks until an entry has been
+// committed, or the context is done, or the log has been closed.
+func (r *Reader) Next(ctx context.Context) (Entry, error) {
+	for {
+		if r.index < len(r.entries) {
+			e := r.entries[r.index]
+			r.index++
+			return e, nil
+		}
+
+		entries, notify, err := r.l.read(r.height)
+		if err != nil {
+			return Entry{}, err
+		}
+
+		if notify == nil {
+			r.entries = entries
+			r.index = 0
+			r.height++
+			continue
+		}
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return Entry{}, ctx.Err()
+		}
+	}
+}
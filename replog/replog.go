@@ -0,0 +1,514 @@
+package replog
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+	"github.com/BDLS-bft/bdls/crypto/blake2b"
+)
+
+const (
+	// MaxEntrySize is the maximum size of a single entry
+	MaxEntrySize = 1024 * 1024
+	// MaxBatchSize is the maximum size of entries proposed at one height
+	MaxBatchSize = 4 * 1024 * 1024
+
+	// LogFileName is the name of the log file in the directory
+	LogFileName = "replog.wal"
+
+	// Record format:
+	// |PayloadLength(4bytes)|Checksum(32bytes)|Payload(PayloadLength)|
+	// Payload: |Height(8bytes)|Batch|
+	recordHeaderSize = 4 + blake2b.Size256
+	// the maximum payload length, a batch proposed by others may exceed
+	// MaxBatchSize slightly.
+	maxPayloadLength = 8 + 2*MaxBatchSize
+)
+
+// Entry is a committed entry in the log
+type Entry struct {
+	Height uint64 // the height at which the entry has been committed
+	Index  int    // the index of the entry in the height
+	Data   []byte // the entry data
+}
+
+// Proposer proposes states to consensus, bdls.IPCPeer and agent.TCPAgent
+// are proposers which serialize the access to consensus.
+type Proposer interface {
+	Propose(s bdls.State)
+}
+
+// Log is a replicated write-ahead log, entries appended to a Log will be
+// batched and proposed to consensus, and the committed entries will be
+// written to a file in height order.
+//
+// Log implements bdls.Application, and is driven by the consensus object
+// it created, the consensus object MUST be accessed with the same
+// synchronization as the Proposer.
+//
+// Batches are signed with the private key of the log which appended the
+// entries, only batches signed by participants will be accepted.
+type Log struct {
+	driver   *bdls.AppDriver
+	proposer Proposer
+
+	file    *os.File
+	offsets []int64 // offsets[h-1] is the offset of the record at height h
+	size    int64   // file size of valid records
+	hash    []byte  // hash chain of all records
+
+	privateKey       *ecdsa.PrivateKey
+	participants     map[bdls.Identity]bool
+	pubKeyToIdentity func(pubkey *ecdsa.PublicKey) bdls.Identity
+
+	origin    bdls.Identity            // public key of this log
+	seq       uint64                   // sequence number of appended entries
+	pending   []pendingEntry           // entries awaiting to be committed
+	proposal  bdls.State               // signed batch of pending entries, nil if pending entries changed
+	committed map[bdls.Identity]uint64 // the highest committed seq of each origin
+
+	notify chan struct{} // closed when new records have been written
+	closed bool
+
+	sync.Mutex
+}
+
+// Open opens the log in the directory, and creates a consensus object with
+// a copy of the config to replicate the log, consensus resumes from the last
+// height in the log. The copy's StateCompare will be set by the log, and
+// MessageValidator will be wrapped to endorse batches proposed by others,
+// see bdls.NewAppDriver for the other fields set from the log.
+//
+// Sequence numbers of appended entries start from the wall clock at Open,
+// entries appended before a restart and not committed yet may still be
+// committed, unless newer entries of this log have been committed before.
+func Open(dir string, c *bdls.Config) (*Log, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, LogFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	config := new(bdls.Config)
+	*config = *c
+
+	l := new(Log)
+	l.file = file
+	l.notify = make(chan struct{})
+	l.committed = make(map[bdls.Identity]uint64)
+	l.privateKey = config.PrivateKey
+	l.pubKeyToIdentity = config.PubKeyToIdentity
+	if l.pubKeyToIdentity == nil {
+		l.pubKeyToIdentity = bdls.DefaultPubKeyToIdentity
+	}
+	l.participants = make(map[bdls.Identity]bool)
+	for _, id := range config.Participants {
+		l.participants[id] = true
+	}
+	if l.privateKey != nil {
+		l.origin = bdls.DefaultPubKeyToIdentity(&l.privateKey.PublicKey)
+	}
+
+	err = l.load()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	l.seq = uint64(time.Now().UnixNano())
+	if l.seq < l.committed[l.origin] {
+		l.seq = l.committed[l.origin]
+	}
+
+	config.StateCompare = compareBatches
+	validator := config.MessageValidator
+	config.MessageValidator = func(c *bdls.Consensus, m *bdls.Message, signed *bdls.SignedProto) bool {
+		if validator != nil && !validator(c, m, signed) {
+			return false
+		}
+		l.endorse(c, m)
+		return true
+	}
+
+	driver, err := bdls.NewAppDriver(l, config)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	l.driver = driver
+	return l, nil
+}
+
+// load scans all records in the log file to rebuild the index, an
+// incomplete record at the end(torn write) will be truncated.
+func (l *Log) load() error {
+	var header [recordHeaderSize]byte
+	for {
+		n, err := l.file.ReadAt(header[:], l.size)
+		if err == io.EOF {
+			if n == 0 {
+				return nil
+			}
+			return l.file.Truncate(l.size)
+		} else if err != nil {
+			return err
+		}
+
+		length := binary.LittleEndian.Uint32(header[:4])
+		if length < 8 || length > maxPayloadLength {
+			return ErrCorruptRecord
+		}
+
+		payload := make([]byte, length)
+		_, err = l.file.ReadAt(payload, l.size+recordHeaderSize)
+		if err == io.EOF {
+			return l.file.Truncate(l.size)
+		} else if err != nil {
+			return err
+		}
+
+		checksum := blake2b.Sum256(payload)
+		if !bytes.Equal(checksum[:], header[4:]) {
+			return ErrCorruptRecord
+		}
+
+		height := binary.LittleEndian.Uint64(payload[:8])
+		if height != uint64(len(l.offsets))+1 {
+			return ErrHeightGap
+		}
+
+		b, err := decodeBatch(payload[8:])
+		if err != nil {
+			return ErrCorruptRecord
+		}
+		l.commit(b.origin, b.entries)
+
+		l.offsets = append(l.offsets, l.size)
+		l.size += recordHeaderSize + int64(length)
+		l.chain(payload)
+	}
+}
+
+// endorse proposes the batch in a <roundchange> message for the next height,
+// participants without pending entries never send <roundchange> messages,
+// endorsing batches from others makes sure 2t+1 participants take part in
+// the round.
+func (l *Log) endorse(c *bdls.Consensus, m *bdls.Message) {
+	if m.Type != bdls.MessageType_RoundChange {
+		return
+	}
+
+	height, _, _ := c.CurrentState()
+	if m.Height == height+1 && l.CheckProposal(m.State) {
+		c.Propose(m.State)
+	}
+}
+
+// chain updates the hash chain with a record payload
+func (l *Log) chain(payload []byte) {
+	hash, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+	hash.Write(l.hash)
+	hash.Write(payload)
+	l.hash = hash.Sum(nil)
+}
+
+// Consensus returns the consensus object which replicates this log
+func (l *Log) Consensus() *bdls.Consensus { return l.driver.Consensus() }
+
+// Err returns the error which stopped the log from applying states,
+// bdls.ErrAppHeightGap will be returned if consensus has jumped over some
+// heights, the missing batches should be fetched from other participants
+// along with their <decide> proofs, and fed to CatchUp.
+func (l *Log) Err() error { return l.driver.Err() }
+
+// CatchUp writes a batch decided at height LastHeight() + 1 with the
+// <decide> proof of it, see bdls.AppDriver.CatchUp.
+func (l *Log) CatchUp(height uint64, s bdls.State, proof *bdls.SignedProto) error {
+	return l.driver.CatchUp(height, s, proof)
+}
+
+// SetProposer sets the proposer to propose appended entries immediately,
+// without a proposer, entries will be proposed in the next <roundchange>.
+func (l *Log) SetProposer(p Proposer) {
+	l.Lock()
+	defer l.Unlock()
+	l.proposer = p
+}
+
+// Append appends an entry to the log, the entry is durable only after it
+// has been committed, which can be observed via Reader.
+func (l *Log) Append(entry []byte) error {
+	if len(entry) > MaxEntrySize {
+		return ErrEntryTooLarge
+	}
+
+	l.Lock()
+	if l.closed {
+		l.Unlock()
+		return ErrClosed
+	}
+
+	l.seq++
+	data := make([]byte, len(entry))
+	copy(data, entry)
+	l.pending = append(l.pending, pendingEntry{seq: l.seq, data: data})
+	l.proposal = nil
+
+	proposer := l.proposer
+	batch := l.batch()
+	l.Unlock()
+
+	// propose without holding the lock, as consensus may apply states
+	if proposer != nil && batch != nil {
+		proposer.Propose(batch)
+	}
+	return nil
+}
+
+// batch encodes and signs pending entries up to MaxBatchSize, the signed
+// batch is kept until pending entries change, so the same entries are
+// always proposed in the same state. The lock must be held.
+func (l *Log) batch() bdls.State {
+	if l.proposal != nil {
+		return l.proposal
+	}
+
+	size := batchHeaderSize
+	var entries []pendingEntry
+	for _, e := range l.pending {
+		size += 8 + binary.MaxVarintLen64 + len(e.data)
+		if size > MaxBatchSize && len(entries) > 0 {
+			break
+		}
+		entries = append(entries, e)
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	b := &batch{entries: entries}
+	b.sign(l.privateKey)
+	l.proposal = encodeBatch(b)
+	return l.proposal
+}
+
+// CheckProposal implements bdls.Application, a proposal must be a well-formed
+// batch with at least one entry in sequence order, signed by a participant.
+func (l *Log) CheckProposal(s bdls.State) bool {
+	if len(s) > maxPayloadLength-8 {
+		return false
+	}
+	b, err := decodeBatch(s)
+	if err != nil || len(b.entries) == 0 {
+		return false
+	}
+
+	var seq uint64
+	for _, e := range b.entries {
+		if e.seq <= seq {
+			return false
+		}
+		seq = e.seq
+	}
+
+	curve := l.privateKey.Curve
+	if !l.participants[l.pubKeyToIdentity(b.publicKey(curve))] {
+		return false
+	}
+	return b.verify(curve)
+}
+
+// dedupe removes the entries which have been committed, the lock must be
+// held. Entries of an origin are always committed in sequence order, as a
+// batch is built from the head of the pending queue, so an entry has been
+// committed if its seq is not above the highest committed seq of its origin.
+// Stale batches with committed entries can still be decided, as others may
+// propose pending entries again before they have applied the height
+// committing them.
+func (l *Log) dedupe(b *batch) []pendingEntry {
+	seq := l.committed[b.origin]
+	var deduped []pendingEntry
+	for _, e := range b.entries {
+		if e.seq > seq {
+			seq = e.seq
+			deduped = append(deduped, e)
+		}
+	}
+	return deduped
+}
+
+// commit marks the entries of origin written to the log as committed, the
+// lock must be held.
+func (l *Log) commit(origin bdls.Identity, entries []pendingEntry) {
+	for _, e := range entries {
+		l.committed[origin] = e.seq
+	}
+}
+
+// BuildProposal implements bdls.Application, all pending entries will be
+// proposed.
+func (l *Log) BuildProposal(height uint64) bdls.State {
+	l.Lock()
+	defer l.Unlock()
+	return l.batch()
+}
+
+// ApplyState implements bdls.Application, the batch without the entries
+// committed at lower heights will be written to the log file and synced before
+// returning, so every entry appears in the log exactly once. The signature is
+// dropped from the record, as it doesn't cover the deduped batch.
+func (l *Log) ApplyState(height uint64, s bdls.State) error {
+	b, err := decodeBatch(s)
+	if err != nil {
+		return err
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+
+	if height != uint64(len(l.offsets))+1 {
+		return ErrHeightGap
+	}
+
+	entries := l.dedupe(b)
+	s = encodeBatch(&batch{origin: b.origin, entries: entries})
+
+	payload := make([]byte, 8+len(s))
+	binary.LittleEndian.PutUint64(payload, height)
+	copy(payload[8:], s)
+
+	record := make([]byte, recordHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(record, uint32(len(payload)))
+	checksum := blake2b.Sum256(payload)
+	copy(record[4:], checksum[:])
+	copy(record[recordHeaderSize:], payload)
+
+	_, err = l.file.WriteAt(record, l.size)
+	if err != nil {
+		return err
+	}
+
+	err = l.file.Sync()
+	if err != nil {
+		return err
+	}
+
+	l.offsets = append(l.offsets, l.size)
+	l.size += int64(len(record))
+	l.chain(payload)
+	l.commit(b.origin, entries)
+
+	// remove committed entries from pending queue
+	o := 0
+	for i := range l.pending {
+		if l.pending[i].seq > l.committed[l.origin] {
+			l.pending[o] = l.pending[i]
+			o++
+		}
+	}
+	if o != len(l.pending) {
+		l.proposal = nil
+	}
+	l.pending = l.pending[:o]
+
+	// wake up readers
+	close(l.notify)
+	l.notify = make(chan struct{})
+	return nil
+}
+
+// LastHeight implements bdls.Application, returns the height of the last
+// record in the log.
+func (l *Log) LastHeight() uint64 {
+	l.Lock()
+	defer l.Unlock()
+	return uint64(len(l.offsets))
+}
+
+// AppHash implements bdls.Application, returns the hash chain of all
+// records in the log.
+func (l *Log) AppHash() []byte {
+	l.Lock()
+	defer l.Unlock()
+	return l.hash
+}
+
+// NumPending returns the number of entries awaiting to be committed
+func (l *Log) NumPending() int {
+	l.Lock()
+	defer l.Unlock()
+	return len(l.pending)
+}
+
+// read reads the committed entries at the given height, if the height has
+// not been committed yet, a channel will be returned to wait on.
+func (l *Log) read(height uint64) ([]Entry, <-chan struct{}, error) {
+	l.Lock()
+	if l.closed {
+		l.Unlock()
+		return nil, nil, ErrClosed
+	}
+
+	if height > uint64(len(l.offsets)) {
+		notify := l.notify
+		l.Unlock()
+		return nil, notify, nil
+	}
+
+	offset := l.offsets[height-1]
+	var size int64
+	if height < uint64(len(l.offsets)) {
+		size = l.offsets[height] - offset
+	} else {
+		size = l.size - offset
+	}
+	l.Unlock()
+
+	// records are immutable once written
+	record := make([]byte, size)
+	_, err := l.file.ReadAt(record, offset)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := decodeBatch(record[recordHeaderSize+8:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries := make([]Entry, len(b.entries))
+	for k := range b.entries {
+		entries[k] = Entry{Height: height, Index: k, Data: b.entries[k].data}
+	}
+	return entries, nil, nil
+}
+
+// Close closes the log file, readers will be waken up with ErrClosed.
+func (l *Log) Close() error {
+	l.Lock()
+	defer l.Unlock()
+	if l.closed {
+		return ErrClosed
+	}
+	l.closed = true
+	close(l.notify)
+	return l.file.Close()
+}
@@ -0,0 +1,355 @@
+package replog
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BDLS-bft/bdls"
+	"github.com/stretchr/testify/assert"
+)
+
+// signedBatch encodes entries into a batch signed by the private key
+func signedBatch(privateKey *ecdsa.PrivateKey, entries ...pendingEntry) bdls.State {
+	b := &batch{entries: entries}
+	b.sign(privateKey)
+	return encodeBatch(b)
+}
+
+func TestBatchEncoding(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+
+	entries := []pendingEntry{
+		{seq: 1, data: []byte("hello")},
+		{seq: 2, data: []byte{}},
+		{seq: 3, data: make([]byte, 1000)},
+	}
+
+	batch := signedBatch(privateKey, entries...)
+	decoded, err := decodeBatch(batch)
+	assert.Nil(t, err)
+	assert.Equal(t, bdls.DefaultPubKeyToIdentity(&privateKey.PublicKey), decoded.origin)
+	assert.True(t, decoded.verify(bdls.S256Curve))
+	assert.Equal(t, len(entries), len(decoded.entries))
+	for k := range entries {
+		assert.Equal(t, entries[k].seq, decoded.entries[k].seq)
+		assert.Equal(t, entries[k].data, decoded.entries[k].data)
+	}
+
+	// malformed
+	_, err = decodeBatch(batch[:len(batch)-1])
+	assert.Equal(t, ErrCorruptBatch, err)
+	_, err = decodeBatch(append(batch, 0))
+	assert.Equal(t, ErrCorruptBatch, err)
+	_, err = decodeBatch(batch[:batchHeaderSize])
+	assert.Equal(t, ErrCorruptBatch, err)
+	_, err = decodeBatch(nil)
+	assert.Equal(t, ErrCorruptBatch, err)
+
+	// tampered
+	tampered := append([]byte{}, batch...)
+	tampered[len(tampered)-1] ^= 0xff
+	decoded, err = decodeBatch(tampered)
+	assert.Nil(t, err)
+	assert.False(t, decoded.verify(bdls.S256Curve))
+
+	// a batch with more entries is larger
+	assert.Equal(t, 1, compareBatches(batch, signedBatch(privateKey, entries[:2]...)))
+	assert.Equal(t, -1, compareBatches(signedBatch(privateKey, entries[:1]...), batch))
+	assert.Equal(t, 0, compareBatches(batch, batch))
+}
+
+// createLogs creates logs replicated over IPCPeers with latency
+func createLogs(t *testing.T, dirs []string, keys []*ecdsa.PrivateKey, latency time.Duration) ([]*Log, []*bdls.IPCPeer) {
+	var participants []bdls.Identity
+	for _, key := range keys {
+		participants = append(participants, bdls.DefaultPubKeyToIdentity(&key.PublicKey))
+	}
+
+	epoch := time.Now()
+	var logs []*Log
+	var peers []*bdls.IPCPeer
+	for i := range keys {
+		config := new(bdls.Config)
+		config.Epoch = epoch
+		config.PrivateKey = keys[i]
+		config.Participants = participants
+
+		l, err := Open(dirs[i], config)
+		assert.Nil(t, err)
+		l.Consensus().SetLatency(latency)
+
+		peer := bdls.NewIPCPeer(l.Consensus(), latency)
+		l.SetProposer(peer)
+		logs = append(logs, l)
+		peers = append(peers, peer)
+	}
+
+	for i := range logs {
+		for j := range peers {
+			if i != j {
+				logs[i].Consensus().Join(peers[j])
+			}
+		}
+	}
+
+	for i := range peers {
+		peers[i].Update()
+	}
+	return logs, peers
+}
+
+func readEntries(t *testing.T, r *Reader, n int) []Entry {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var entries []Entry
+	for len(entries) < n {
+		e, err := r.Next(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestReplicatedLog(t *testing.T) {
+	var keys []*ecdsa.PrivateKey
+	var dirs []string
+	for i := 0; i < 4; i++ {
+		privateKey, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+		assert.Nil(t, err)
+		keys = append(keys, privateKey)
+		dirs = append(dirs, filepath.Join(t.TempDir(), fmt.Sprint(i)))
+	}
+
+	logs, peers := createLogs(t, dirs, keys, 50*time.Millisecond)
+
+	// tailing readers
+	var readers []*Reader
+	for i := range logs {
+		readers = append(readers, logs[i].NewReader(1))
+	}
+
+	const numEntries = 20
+	expected := make(map[string]bool)
+	for i := 0; i < numEntries; i++ {
+		entry := fmt.Sprintf("entry %v", i)
+		expected[entry] = true
+		assert.Nil(t, logs[i%len(logs)].Append([]byte(entry)))
+	}
+
+	var all [][]Entry
+	for i := range readers {
+		all = append(all, readEntries(t, readers[i], numEntries))
+	}
+
+	// every log has the same entries in the same order without gaps
+	var lastHeight uint64
+	for k, e := range all[0] {
+		assert.True(t, expected[string(e.Data)])
+		delete(expected, string(e.Data))
+		assert.True(t, e.Height == lastHeight || e.Height == lastHeight+1)
+		lastHeight = e.Height
+
+		for i := range all {
+			assert.Equal(t, e, all[i][k])
+		}
+	}
+	assert.Equal(t, 0, len(expected))
+
+	for i := range logs {
+		assert.Nil(t, logs[i].Err())
+		assert.Equal(t, 0, logs[i].NumPending())
+	}
+
+	// stop consensus, and reopen the logs
+	for i := range peers {
+		peers[i].Close()
+	}
+
+	var hashes [][]byte
+	var heights []uint64
+	for i := range logs {
+		hashes = append(hashes, logs[i].AppHash())
+		heights = append(heights, logs[i].LastHeight())
+		assert.Nil(t, logs[i].Close())
+	}
+	assert.Equal(t, ErrClosed, logs[0].Append([]byte("closed")))
+
+	logs, peers = createLogs(t, dirs, keys, 50*time.Millisecond)
+	defer func() {
+		for i := range peers {
+			peers[i].Close()
+			logs[i].Close()
+		}
+	}()
+
+	for i := range logs {
+		assert.Equal(t, hashes[i], logs[i].AppHash())
+		assert.Equal(t, heights[i], logs[i].LastHeight())
+		height, _, _ := logs[i].Consensus().CurrentState()
+		assert.Equal(t, heights[i], height)
+		assert.Equal(t, all[i], readEntries(t, logs[i].NewReader(0), numEntries))
+	}
+
+	// the log continues after restart
+	assert.Nil(t, logs[0].Append([]byte("after restart")))
+	for i := range logs {
+		e := readEntries(t, logs[i].NewReader(heights[i]+1), 1)[0]
+		assert.Equal(t, []byte("after restart"), e.Data)
+	}
+}
+
+func TestTornWrite(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	var participants []bdls.Identity
+	for i := 0; i < 4; i++ {
+		participants = append(participants, bdls.DefaultPubKeyToIdentity(&privateKey.PublicKey))
+	}
+
+	open := func(dir string) (*Log, error) {
+		config := new(bdls.Config)
+		config.Epoch = time.Now()
+		config.PrivateKey = privateKey
+		config.Participants = participants
+		return Open(dir, config)
+	}
+
+	dir := t.TempDir()
+	l, err := open(dir)
+	assert.Nil(t, err)
+
+	batch := signedBatch(privateKey, pendingEntry{seq: 1, data: []byte("hello")})
+	assert.Nil(t, l.ApplyState(1, batch))
+	assert.Equal(t, ErrHeightGap, l.ApplyState(3, batch))
+	assert.Nil(t, l.ApplyState(2, batch))
+	size := l.size
+	assert.Nil(t, l.Close())
+
+	// an incomplete record will be truncated
+	path := filepath.Join(dir, LogFileName)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	assert.Nil(t, err)
+	_, err = f.Write([]byte{100, 0, 0, 0, 1, 2, 3})
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+
+	l, err = open(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), l.LastHeight())
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, size, info.Size())
+	assert.Nil(t, l.Close())
+
+	// a corrupt record will be reported
+	f, err = os.OpenFile(path, os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	_, err = f.WriteAt([]byte{0xff}, size-1)
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+
+	_, err = open(dir)
+	assert.Equal(t, ErrCorruptRecord, err)
+}
+
+func TestDuplicateEntries(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	var participants []bdls.Identity
+	for i := 0; i < 4; i++ {
+		participants = append(participants, bdls.DefaultPubKeyToIdentity(&privateKey.PublicKey))
+	}
+
+	open := func(dir string) *Log {
+		config := new(bdls.Config)
+		config.Epoch = time.Now()
+		config.PrivateKey = privateKey
+		config.Participants = participants
+		l, err := Open(dir, config)
+		assert.Nil(t, err)
+		return l
+	}
+
+	entry := func(seq uint64) pendingEntry {
+		return pendingEntry{seq: seq, data: []byte(fmt.Sprint(seq))}
+	}
+
+	read := func(l *Log, height uint64) (data []string) {
+		entries, _, err := l.read(height)
+		assert.Nil(t, err)
+		for _, e := range entries {
+			data = append(data, string(e.Data))
+		}
+		return data
+	}
+
+	dir := t.TempDir()
+	l := open(dir)
+
+	// entries must be in sequence order
+	assert.False(t, l.CheckProposal(signedBatch(privateKey, entry(2), entry(1))))
+	assert.False(t, l.CheckProposal(signedBatch(privateKey, entry(1), entry(1))))
+	assert.True(t, l.CheckProposal(signedBatch(privateKey, entry(1), entry(2))))
+
+	// a stale batch re-proposing committed entries
+	assert.Nil(t, l.ApplyState(1, signedBatch(privateKey, entry(1), entry(2))))
+	assert.Nil(t, l.ApplyState(2, signedBatch(privateKey, entry(1), entry(2), entry(3))))
+	assert.Equal(t, []string{"1", "2"}, read(l, 1))
+	assert.Equal(t, []string{"3"}, read(l, 2))
+	assert.Nil(t, l.Close())
+
+	// committed entries are known after restart
+	l = open(dir)
+	assert.Nil(t, l.ApplyState(3, signedBatch(privateKey, entry(3))))
+	assert.Nil(t, l.ApplyState(4, signedBatch(privateKey, entry(3), entry(4))))
+	assert.Empty(t, read(l, 3))
+	assert.Equal(t, []string{"4"}, read(l, 4))
+	assert.Nil(t, l.Close())
+}
+
+func TestForgedBatch(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	outsider, err := ecdsa.GenerateKey(bdls.S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	var participants []bdls.Identity
+	for i := 0; i < 4; i++ {
+		participants = append(participants, bdls.DefaultPubKeyToIdentity(&privateKey.PublicKey))
+	}
+
+	config := new(bdls.Config)
+	config.Epoch = time.Now()
+	config.PrivateKey = privateKey
+	config.Participants = participants
+	l, err := Open(t.TempDir(), config)
+	assert.Nil(t, err)
+	defer l.Close()
+
+	// the caller's config is left untouched
+	assert.Nil(t, config.StateCompare)
+	assert.Nil(t, config.StateValidate)
+	assert.Nil(t, config.MessageValidator)
+
+	entry := pendingEntry{seq: 1, data: []byte("hello")}
+	assert.True(t, l.CheckProposal(signedBatch(privateKey, entry)))
+
+	// batches of non-participants
+	assert.False(t, l.CheckProposal(signedBatch(outsider, entry)))
+
+	// entries of a participant forged by others
+	b := &batch{entries: []pendingEntry{{seq: math.MaxUint64, data: []byte("forged")}}}
+	b.sign(outsider)
+	b.origin = bdls.DefaultPubKeyToIdentity(&privateKey.PublicKey)
+	assert.False(t, l.CheckProposal(encodeBatch(b)))
+}
@@ -0,0 +1,103 @@
+package bdls
+
+// RoundLeader is the leader of a round
+type RoundLeader struct {
+	Round  uint64
+	Leader Identity
+}
+
+// ParticipationReport summarizes who has participated in deciding a height,
+// it's built from the <decide> proof and the rounds seen at that height.
+type ParticipationReport struct {
+	// the decided height
+	Height uint64
+	// the round at which the height was decided
+	Round uint64
+	// Partial is set if this node has jumped over heights to the decided
+	// height, the rounds seen belong to an earlier height, so FailedLeaders
+	// and RoundChangers are left empty.
+	Partial bool
+	// signers of <commit> messages to the decided state in the <decide> proof
+	Committers []Identity
+	// participants which have no <commit> message in the <decide> proof
+	Missing []Identity
+	// leaders of the rounds entered by this node before the decided round,
+	// rounds skipped through round change are not included
+	FailedLeaders []RoundLeader
+	// participants which have sent <roundchange> messages seen by this node
+	// at this height
+	RoundChangers []Identity
+}
+
+// participationReport builds the report for the given height & round from
+// the latest <decide> proof and the rounds list, it MUST be called before
+// the rounds being cleared.
+func (c *Consensus) participationReport(height uint64, round uint64, s State) *ParticipationReport {
+	report := new(ParticipationReport)
+	report.Height = height
+	report.Round = round
+	report.Partial = height != c.latestHeight+1
+
+	// signers of <commit> messages to the decided state
+	committed := make(map[Identity]bool)
+	if c.latestProof != nil {
+		m, err := DecodeMessage(c.latestProof.Message)
+		if err == nil && m.Height == height {
+			stateHash := c.stateHash(s)
+			for _, proof := range m.Proof {
+				mProof, err := DecodeMessage(proof.Message)
+				if err != nil {
+					continue
+				}
+
+				if mProof.Type == MessageType_Commit && c.stateHash(mProof.State) == stateHash {
+					committed[c.pubKeyToIdentity(proof.PublicKey(c.curve))] = true
+				}
+			}
+		}
+	}
+
+	// participants which sent <roundchange> at this height
+	roundChanged := make(map[Identity]bool)
+	for elem := c.rounds.Front(); elem != nil && !report.Partial; elem = elem.Next() {
+		cr := elem.Value.(*consensusRound)
+		for k := range cr.roundChanges {
+			roundChanged[c.pubKeyToIdentity(cr.roundChanges[k].Signed.PublicKey(c.curve))] = true
+		}
+	}
+
+	// keep the order of participants
+	seen := make(map[Identity]bool)
+	for _, id := range c.participants {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if committed[id] {
+			report.Committers = append(report.Committers, id)
+		} else {
+			report.Missing = append(report.Missing, id)
+		}
+
+		if roundChanged[id] {
+			report.RoundChangers = append(report.RoundChangers, id)
+		}
+	}
+
+	if !report.Partial {
+		for _, r := range c.roundsEntered {
+			if r < round {
+				report.FailedLeaders = append(report.FailedLeaders, RoundLeader{r, c.roundLeader(r)})
+			}
+		}
+	}
+
+	return report
+}
+
+// CurrentParticipationReport returns the participation report of current
+// height, it returns nil if no height has been decided yet.
+func (c *Consensus) CurrentParticipationReport() *ParticipationReport {
+	return c.latestReport
+}
@@ -0,0 +1,320 @@
+package bdls
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/binary"
+	"math/big"
+	"time"
+
+	"github.com/BDLS-bft/bdls/crypto/blake2b"
+
+	proto "github.com/gogo/protobuf/proto"
+)
+
+const (
+	// RequestSignaturePrefix is the prefix for signing a client request
+	RequestSignaturePrefix = "BDLS_CLIENT_REQUEST"
+
+	// MaxPendingRequests is the maximum number of client requests awaiting
+	// to be included in a proposal
+	MaxPendingRequests = 4096
+
+	// MaxRequestLifetime defines how many heights ahead a request can expire,
+	// the ids of decided requests are kept until they expire to reject
+	// duplicated submissions, so a signed request can never be replayed.
+	MaxRequestLifetime = 64
+
+	// RequestPendingTimeout is the period a request will be kept pending
+	// if it has not been included, even if it has not expired.
+	RequestPendingTimeout = 5 * time.Minute
+)
+
+// RequestID identifies a client request by the client's identity and the
+// id chosen by the client
+type RequestID struct {
+	Client Identity // identity of the client
+	ID     uint64   // request id chosen by the client
+}
+
+// Receipt proves a client request has been included in a decided state
+type Receipt struct {
+	Request     RequestID    // the request included
+	RequestHash []byte       // the hash of the signed request included
+	Height      uint64       // the height decided
+	Proof       *SignedProto // the <decide> message of the height
+}
+
+// pendingRequest is a client request awaiting to be included
+type pendingRequest struct {
+	request *ClientRequest
+	id      RequestID
+	hash    []byte    // hash of the signed request
+	expires time.Time // the time to drop the request if not included
+}
+
+// Hash concats and hash as follows:
+// blake2b(RequestSignaturePrefix + [len_32bit(chainid) + chainid] + pubkey.X + pubkey.Y + id + expiryheight + len_32bit(payload) + payload)
+//
+// integers are encoded in little endian, the chain id is only written when
+// it's not empty.
+func (r *ClientRequest) Hash() []byte {
+	hash, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	// write prefix
+	_, err = hash.Write([]byte(RequestSignaturePrefix))
+	if err != nil {
+		panic(err)
+	}
+
+	// write chain id
+	if len(r.ChainID) > 0 {
+		err = binary.Write(hash, binary.LittleEndian, uint32(len(r.ChainID)))
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = hash.Write(r.ChainID)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	// write X & Y
+	_, err = hash.Write(r.X[:])
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = hash.Write(r.Y[:])
+	if err != nil {
+		panic(err)
+	}
+
+	// write id & expiry height
+	err = binary.Write(hash, binary.LittleEndian, r.ID)
+	if err != nil {
+		panic(err)
+	}
+
+	err = binary.Write(hash, binary.LittleEndian, r.ExpiryHeight)
+	if err != nil {
+		panic(err)
+	}
+
+	// write payload
+	err = binary.Write(hash, binary.LittleEndian, uint32(len(r.Payload)))
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = hash.Write(r.Payload)
+	if err != nil {
+		panic(err)
+	}
+
+	return hash.Sum(nil)
+}
+
+// Sign the request with the client's private key, the ID, ExpiryHeight,
+// Payload and ChainID fields should be set before signing.
+func (r *ClientRequest) Sign(privateKey *ecdsa.PrivateKey) {
+	err := r.X.Unmarshal(privateKey.PublicKey.X.Bytes())
+	if err != nil {
+		panic(err)
+	}
+	err = r.Y.Unmarshal(privateKey.PublicKey.Y.Bytes())
+	if err != nil {
+		panic(err)
+	}
+
+	rr, s, err := ecdsa.Sign(rand.Reader, privateKey, r.Hash())
+	if err != nil {
+		panic(err)
+	}
+	r.R = rr.Bytes()
+	r.S = s.Bytes()
+}
+
+// Verify the signature of this request
+func (r *ClientRequest) Verify(curve elliptic.Curve) bool {
+	var R, S big.Int
+	R.SetBytes(r.R)
+	S.SetBytes(r.S)
+	return ecdsa.Verify(r.PublicKey(curve), r.Hash(), &R, &S)
+}
+
+// PublicKey returns the public key of the client
+func (r *ClientRequest) PublicKey(curve elliptic.Curve) *ecdsa.PublicKey {
+	pubkey := new(ecdsa.PublicKey)
+	pubkey.Curve = curve
+	pubkey.X = big.NewInt(0).SetBytes(r.X[:])
+	pubkey.Y = big.NewInt(0).SetBytes(r.Y[:])
+	return pubkey
+}
+
+// RequestID returns the id of a client request, the client's identity is
+// derived with Config.PubKeyToIdentity.
+func (c *Consensus) RequestID(r *ClientRequest) RequestID {
+	return RequestID{Client: c.pubKeyToIdentity(r.PublicKey(c.curve)), ID: r.ID}
+}
+
+// SubmitRequest submits an encoded client request, the request will be
+// pending until it's included in a decided state, it has expired, or
+// RequestPendingTimeout has passed since now, it's the application's
+// responsibility to include PendingRequests() in its proposals.
+//
+// a request will be rejected if it's signed for another chain, it expires
+// at the decided heights or after MaxRequestLifetime heights, or it has been
+// submitted before, callers should only forward requests accepted to other
+// participants.
+func (c *Consensus) SubmitRequest(bts []byte, now time.Time) error {
+	r := new(ClientRequest)
+	err := proto.Unmarshal(bts, r)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(r.ChainID, c.chainID) {
+		return ErrRequestChainID
+	}
+
+	if r.ExpiryHeight <= c.latestHeight {
+		return ErrRequestExpired
+	}
+
+	if r.ExpiryHeight > c.latestHeight+MaxRequestLifetime {
+		return ErrRequestLifetime
+	}
+
+	if !r.Verify(c.curve) {
+		return ErrRequestSignature
+	}
+
+	id := c.RequestID(r)
+	if _, ok := c.pendingIndex[id]; ok {
+		return ErrRequestDuplicated
+	}
+
+	if _, ok := c.decidedRequests[id]; ok {
+		return ErrRequestDuplicated
+	}
+
+	c.prunePendingRequests(now)
+	if len(c.pendingRequests) >= MaxPendingRequests {
+		return ErrRequestPoolFull
+	}
+
+	p := &pendingRequest{request: r, id: id, hash: r.Hash(), expires: now.Add(RequestPendingTimeout)}
+	c.pendingRequests = append(c.pendingRequests, p)
+	c.pendingIndex[id] = p
+	return nil
+}
+
+// PendingRequests returns client requests awaiting to be included in a
+// proposal, in submission order.
+func (c *Consensus) PendingRequests() []*ClientRequest {
+	requests := make([]*ClientRequest, len(c.pendingRequests))
+	for k := range c.pendingRequests {
+		requests[k] = c.pendingRequests[k].request
+	}
+	return requests
+}
+
+// prunePendingRequests removes pending requests which have been decided,
+// expired at the latest height, or timed out at now.
+func (c *Consensus) prunePendingRequests(now time.Time) {
+	pending := c.pendingRequests[:0]
+	for _, p := range c.pendingRequests {
+		// decided
+		if c.pendingIndex[p.id] != p {
+			continue
+		}
+
+		if p.request.ExpiryHeight <= c.latestHeight || !now.Before(p.expires) {
+			delete(c.pendingIndex, p.id)
+			continue
+		}
+		pending = append(pending, p)
+	}
+	for k := len(pending); k < len(c.pendingRequests); k++ {
+		c.pendingRequests[k] = nil // avoid memory leak
+	}
+	c.pendingRequests = pending
+}
+
+// issueReceipts removes requests included in the decided state from pending
+// requests, and issues receipts for the requests submitted to this node.
+func (c *Consensus) issueReceipts(height uint64, s State, now time.Time) {
+	// forget decided requests which have expired, they cannot be
+	// submitted again.
+	for id, expiry := range c.decidedRequests {
+		if expiry <= height {
+			delete(c.decidedRequests, id)
+		}
+	}
+
+	if c.requestExtractor != nil {
+		for _, r := range c.requestExtractor(s) {
+			if r == nil || r.ExpiryHeight < height {
+				continue
+			}
+
+			// the signed request must be known, or carry a valid signature
+			id := c.RequestID(r)
+			hash := r.Hash()
+			p := c.pendingIndex[id]
+			known := p != nil && bytes.Equal(p.hash, hash)
+			if !known && !r.Verify(c.curve) {
+				continue
+			}
+
+			c.decidedRequests[id] = r.ExpiryHeight
+			delete(c.pendingIndex, id)
+			if known && c.receiptCallback != nil {
+				c.receiptCallback(&Receipt{Request: id, RequestHash: hash, Height: height, Proof: c.latestProof})
+			}
+		}
+	}
+
+	c.prunePendingRequests(now)
+}
+
+// ValidateReceipt validates a receipt for non-participants, the <decide>
+// proof must be valid, and the signed request with RequestHash must be
+// included in the decided state if Config.RequestExtractor has set. States
+// are validated synchronously, see ValidateDecideMessage.
+func (c *Consensus) ValidateReceipt(receipt *Receipt) error {
+	if receipt.Proof == nil {
+		return ErrReceiptProof
+	}
+
+	m, err := DecodeMessage(receipt.Proof.Message)
+	if err != nil {
+		return err
+	}
+
+	if m.Height != receipt.Height {
+		return ErrReceiptHeight
+	}
+
+	err = c.validateDecideMessage(receipt.Proof, m.State)
+	if err != nil {
+		return err
+	}
+
+	if c.requestExtractor != nil {
+		for _, r := range c.requestExtractor(m.State) {
+			if r != nil && c.RequestID(r) == receipt.Request && bytes.Equal(r.Hash(), receipt.RequestHash) {
+				return nil
+			}
+		}
+		return ErrReceiptRequest
+	}
+	return nil
+}
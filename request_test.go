@@ -0,0 +1,169 @@
+package bdls
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	proto "github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+// createClientRequest creates an encoded client request signed by the key,
+// which expires at height 20.
+func createClientRequest(t *testing.T, id uint64, payload []byte, chainID []byte, privateKey *ecdsa.PrivateKey) (*ClientRequest, []byte) {
+	r := new(ClientRequest)
+	r.ID = id
+	r.ExpiryHeight = 20
+	r.Payload = payload
+	r.ChainID = chainID
+	r.Sign(privateKey)
+
+	bts, err := proto.Marshal(r)
+	assert.Nil(t, err)
+	return r, bts
+}
+
+func TestClientRequestSign(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+	assert.Nil(t, err)
+
+	r, _ := createClientRequest(t, 1, []byte("payload"), nil, privateKey)
+	assert.True(t, r.Verify(S256Curve))
+
+	r.ID++
+	assert.False(t, r.Verify(S256Curve))
+	r.ID--
+	r.ChainID = []byte("another chain")
+	assert.False(t, r.Verify(S256Curve))
+	r.ChainID = nil
+	r.ExpiryHeight++
+	assert.False(t, r.Verify(S256Curve))
+}
+
+func TestSubmitRequest(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	consensus := createConsensus(t, 0, 0, nil)
+	now := time.Now()
+
+	r, bts := createClientRequest(t, 1, []byte("payload"), nil, privateKey)
+	assert.Nil(t, consensus.SubmitRequest(bts, now))
+	assert.Equal(t, ErrRequestDuplicated, consensus.SubmitRequest(bts, now))
+
+	// the same id from another client
+	another, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	_, bts = createClientRequest(t, 1, []byte("payload"), nil, another)
+	assert.Nil(t, consensus.SubmitRequest(bts, now))
+
+	pending := consensus.PendingRequests()
+	assert.Equal(t, 2, len(pending))
+	assert.Equal(t, consensus.RequestID(r), consensus.RequestID(pending[0]))
+
+	// signed for another chain
+	_, bts = createClientRequest(t, 2, []byte("payload"), []byte("another chain"), privateKey)
+	assert.Equal(t, ErrRequestChainID, consensus.SubmitRequest(bts, now))
+
+	// tampered
+	r.Payload = []byte("tampered")
+	bts, err = proto.Marshal(r)
+	assert.Nil(t, err)
+	assert.Equal(t, ErrRequestSignature, consensus.SubmitRequest(bts, now))
+	assert.Equal(t, 2, len(consensus.PendingRequests()))
+
+	// expired, or expires too far ahead
+	consensus.latestHeight = 10
+	r, _ = createClientRequest(t, 3, []byte("payload"), nil, privateKey)
+	r.ExpiryHeight = 10
+	r.Sign(privateKey)
+	bts, err = proto.Marshal(r)
+	assert.Nil(t, err)
+	assert.Equal(t, ErrRequestExpired, consensus.SubmitRequest(bts, now))
+	r.ExpiryHeight = 10 + MaxRequestLifetime + 1
+	r.Sign(privateKey)
+	bts, err = proto.Marshal(r)
+	assert.Nil(t, err)
+	assert.Equal(t, ErrRequestLifetime, consensus.SubmitRequest(bts, now))
+
+	// pending requests time out
+	r.ExpiryHeight = 10 + MaxRequestLifetime
+	r.Sign(privateKey)
+	bts, err = proto.Marshal(r)
+	assert.Nil(t, err)
+	assert.Nil(t, consensus.SubmitRequest(bts, now.Add(RequestPendingTimeout)))
+	pending = consensus.PendingRequests()
+	assert.Equal(t, 1, len(pending))
+	assert.Equal(t, uint64(3), pending[0].ID)
+}
+
+func TestReceipt(t *testing.T) {
+	m, sp, leaderKey, proofKeys := createDecideMessage(t, 20, 10, 3, 10, 3)
+	clientKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+	assert.Nil(t, err)
+
+	consensus := createConsensus(t, 9, 3, proofKeys)
+	consensus.SetLeader(&leaderKey.PublicKey)
+	now := time.Now()
+
+	included, bts := createClientRequest(t, 1, []byte("included"), nil, clientKey)
+	assert.Nil(t, consensus.SubmitRequest(bts, now))
+	_, bts = createClientRequest(t, 2, []byte("not included"), nil, clientKey)
+	assert.Nil(t, consensus.SubmitRequest(bts, now))
+
+	// a request decided without being submitted here
+	other, _ := createClientRequest(t, 3, []byte("other"), nil, clientKey)
+
+	id := consensus.RequestID(included)
+	extractor := func(s State) []*ClientRequest { return []*ClientRequest{included, other} }
+	consensus.requestExtractor = extractor
+	var receipts []*Receipt
+	consensus.receiptCallback = func(receipt *Receipt) { receipts = append(receipts, receipt) }
+
+	decide, err := proto.Marshal(sp)
+	assert.Nil(t, err)
+	assert.Nil(t, consensus.ReceiveMessage(decide, now))
+
+	// receipt for the included request only
+	assert.Equal(t, 1, len(receipts))
+	assert.Equal(t, id, receipts[0].Request)
+	assert.Equal(t, included.Hash(), receipts[0].RequestHash)
+	assert.Equal(t, m.Height, receipts[0].Height)
+	assert.Equal(t, consensus.CurrentProof(), receipts[0].Proof)
+
+	// decided requests are no longer pending, and cannot be resubmitted
+	pending := consensus.PendingRequests()
+	assert.Equal(t, 1, len(pending))
+	assert.Equal(t, uint64(2), pending[0].ID)
+	_, bts = createClientRequest(t, 1, []byte("included"), nil, clientKey)
+	assert.Equal(t, ErrRequestDuplicated, consensus.SubmitRequest(bts, now))
+	_, bts = createClientRequest(t, 3, []byte("other"), nil, clientKey)
+	assert.Equal(t, ErrRequestDuplicated, consensus.SubmitRequest(bts, now))
+
+	// validate the receipt as a non-participant
+	validator := createConsensus(t, 9, 3, proofKeys)
+	validator.SetLeader(&leaderKey.PublicKey)
+	validator.requestExtractor = extractor
+	assert.Nil(t, validator.ValidateReceipt(receipts[0]))
+
+	receipt := *receipts[0]
+	receipt.Height++
+	assert.Equal(t, ErrReceiptHeight, validator.ValidateReceipt(&receipt))
+	receipt = *receipts[0]
+	receipt.Request.ID = 2
+	assert.Equal(t, ErrReceiptRequest, validator.ValidateReceipt(&receipt))
+	receipt = *receipts[0]
+	receipt.RequestHash = other.Hash()
+	assert.Equal(t, ErrReceiptRequest, validator.ValidateReceipt(&receipt))
+	receipt.Proof = nil
+	assert.Equal(t, ErrReceiptProof, validator.ValidateReceipt(&receipt))
+
+	// decided requests are forgotten once expired, and pending requests
+	// expired are dropped
+	consensus.latestHeight = 20
+	consensus.requestExtractor = nil
+	consensus.issueReceipts(20, nil, now)
+	assert.Equal(t, 0, len(consensus.decidedRequests))
+	assert.Equal(t, 0, len(consensus.PendingRequests()))
+}
@@ -0,0 +1,111 @@
+package bdls
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// DefaultRunnerQueueSize is the default buffer size of Runner's channels
+	DefaultRunnerQueueSize = 128
+)
+
+// Decision is a state decided at some height, emitted by Runner.
+type Decision struct {
+	Height uint64       // decided height
+	Round  uint64       // the round at which the height was decided
+	State  State        // decided state
+	Proof  *SignedProto // the <decide> message to prove the state
+}
+
+// Runner drives a Consensus object in its own goroutine, messages and
+// proposals are fed in via channels, and decisions are emitted on an output
+// channel. Update will be called only at the next pending deadline of the
+// consensus.
+//
+// Once Run has been called, the consensus object MUST NOT be accessed from
+// outside, as Consensus is not thread-safe.
+type Runner struct {
+	c *Consensus
+
+	chMessages  chan []byte
+	chProposals chan State
+	chDecisions chan Decision
+
+	// the last height emitted
+	lastHeight uint64
+}
+
+// NewRunner creates a runner for the given consensus object.
+func NewRunner(c *Consensus) *Runner {
+	r := new(Runner)
+	r.c = c
+	r.chMessages = make(chan []byte, DefaultRunnerQueueSize)
+	r.chProposals = make(chan State, DefaultRunnerQueueSize)
+	r.chDecisions = make(chan Decision, DefaultRunnerQueueSize)
+	r.lastHeight, _, _ = c.CurrentState()
+	return r
+}
+
+// Messages returns the channel to input consensus messages
+func (r *Runner) Messages() chan<- []byte { return r.chMessages }
+
+// Proposals returns the channel to propose states
+func (r *Runner) Proposals() chan<- State { return r.chProposals }
+
+// Decisions returns the channel of decided states, one for each new height
+// this runner has seen, in ascending order of height.
+func (r *Runner) Decisions() <-chan Decision { return r.chDecisions }
+
+// Run drives the consensus until the context is done, it returns the error
+// from the context.
+func (r *Runner) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case bts := <-r.chMessages:
+			_ = r.c.ReceiveMessage(bts, time.Now())
+		case s := <-r.chProposals:
+			r.c.Propose(s)
+		case <-timer.C:
+			_ = r.c.Update(time.Now())
+		case <-r.c.ValidationNotify():
+			_ = r.c.Update(time.Now())
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if err := r.emit(ctx); err != nil {
+			return err
+		}
+
+		// schedule at next deadline
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(time.Until(r.c.NextDeadline()))
+	}
+}
+
+// emit sends the decision to the output channel if a new height has been
+// decided.
+func (r *Runner) emit(ctx context.Context) error {
+	height, round, state := r.c.CurrentState()
+	if height <= r.lastHeight {
+		return nil
+	}
+	r.lastHeight = height
+
+	decision := Decision{Height: height, Round: round, State: state, Proof: r.c.CurrentProof()}
+	select {
+	case r.chDecisions <- decision:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,115 @@
+package bdls
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// runnerPeer delivers messages to a runner asynchronously
+type runnerPeer struct {
+	ctx    context.Context
+	key    *ecdsa.PublicKey
+	runner *Runner
+}
+
+func (p *runnerPeer) GetPublicKey() *ecdsa.PublicKey { return p.key }
+func (p *runnerPeer) RemoteAddr() net.Addr           { return fakeAddress(fmt.Sprint(unsafe.Pointer(p))) }
+func (p *runnerPeer) Send(msg []byte) error {
+	go func() {
+		select {
+		case p.runner.Messages() <- msg:
+		case <-p.ctx.Done():
+		}
+	}()
+	return nil
+}
+
+func TestRunner(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var keys []*ecdsa.PrivateKey
+	var participants []Identity
+	for i := 0; i < 4; i++ {
+		privateKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+		assert.Nil(t, err)
+		keys = append(keys, privateKey)
+		participants = append(participants, DefaultPubKeyToIdentity(&privateKey.PublicKey))
+	}
+
+	epoch := time.Now()
+	var consensuses []*Consensus
+	var runners []*Runner
+	for i := range keys {
+		config := new(Config)
+		config.Epoch = epoch
+		config.PrivateKey = keys[i]
+		config.Participants = participants
+		config.StateCompare = func(a State, b State) int { return bytes.Compare(a, b) }
+		config.StateValidate = func(a State) bool { return true }
+
+		consensus, err := NewConsensus(config)
+		assert.Nil(t, err)
+		consensus.SetLatency(50 * time.Millisecond)
+		consensuses = append(consensuses, consensus)
+		runners = append(runners, NewRunner(consensus))
+	}
+
+	// full connected mesh
+	for i := range consensuses {
+		for j := range runners {
+			if i != j {
+				consensuses[i].Join(&runnerPeer{ctx: ctx, key: &keys[j].PublicKey, runner: runners[j]})
+			}
+		}
+	}
+
+	errs := make(chan error, len(runners))
+	for i := range runners {
+		go func(r *Runner) { errs <- r.Run(ctx) }(runners[i])
+	}
+
+	// every runner proposes at each height, and all runners must decide
+	// on the same state in ascending order of height.
+	var lastHeight = make([]uint64, len(runners))
+	for height := uint64(1); height <= 3; height++ {
+		for i := range runners {
+			runners[i].Proposals() <- State(fmt.Sprint(i, height))
+		}
+
+		var states []State
+		for i := range runners {
+			for lastHeight[i] < height {
+				select {
+				case d := <-runners[i].Decisions():
+					assert.Greater(t, d.Height, lastHeight[i])
+					assert.NotNil(t, d.Proof)
+					lastHeight[i] = d.Height
+					if d.Height == height {
+						states = append(states, d.State)
+					}
+				case <-time.After(30 * time.Second):
+					t.Fatal("runner has not decided in time")
+				}
+			}
+		}
+
+		for k := range states {
+			assert.Equal(t, states[0], states[k])
+		}
+	}
+
+	cancel()
+	for range runners {
+		assert.Equal(t, context.Canceled, <-errs)
+	}
+}
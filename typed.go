@@ -0,0 +1,189 @@
+package bdls
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// MaxTypedCacheSize is the maximum number of decoded values cached by Typed
+// within a height, an arbitrary value will be evicted if the cache is full.
+const MaxTypedCacheSize = 1024
+
+// Codec encodes and decodes values of T as states, the encoding MUST be
+// deterministic, so identical values are identical states.
+type Codec[T any] interface {
+	Encode(v T) (State, error)
+	Decode(s State) (T, error)
+}
+
+// TypedConfig defines typed callbacks for Typed consensus
+type TypedConfig[T any] struct {
+	// Codec to encode and decode values as states
+	Codec Codec[T]
+
+	// Compare is the typed StateCompare function, states which cannot be
+	// decoded are smaller than any decoded value.
+	Compare func(a T, b T) int
+
+	// Validate is the typed StateValidate function, states which cannot be
+	// decoded are invalid.
+	Validate func(v T) bool
+
+	// DecideCallback will be called if not nil with the decided value.
+	DecideCallback func(height uint64, round uint64, v T, proof *SignedProto)
+}
+
+// Typed wraps Consensus to propose and decide values of T, states are
+// decoded once and cached by StateHash within a height, at most
+// MaxTypedCacheSize values are cached.
+//
+// As Consensus, Typed is not thread-safe except the callbacks set in config,
+// which may be called from validation goroutines in async validation mode.
+type Typed[T any] struct {
+	consensus *Consensus
+	typed     TypedConfig[T]
+
+	// decoded values cached by StateHash at current height
+	cache   map[StateHash]typedEntry[T]
+	cacheMu sync.Mutex
+}
+
+// typedEntry is a cached decoding result
+type typedEntry[T any] struct {
+	v   T
+	err error
+}
+
+// NewTyped creates a typed consensus object with a copy of the config, the
+// copy's StateCompare and StateValidate will be set from the typed config,
+// and DecideCallback will be wrapped to deliver decided values after the
+// previous callback.
+func NewTyped[T any](c *Config, typed *TypedConfig[T]) (*Typed[T], error) {
+	t := new(Typed[T])
+	t.typed = *typed
+	t.cache = make(map[StateHash]typedEntry[T])
+
+	config := new(Config)
+	*config = *c
+
+	config.StateCompare = t.compare
+	config.StateValidate = t.validate
+
+	callback := config.DecideCallback
+	config.DecideCallback = func(height uint64, round uint64, s State, proof *SignedProto) {
+		if callback != nil {
+			callback(height, round, s, proof)
+		}
+		t.decided(height, round, s, proof)
+	}
+
+	consensus, err := NewConsensus(config)
+	if err != nil {
+		return nil, err
+	}
+	t.consensus = consensus
+	return t, nil
+}
+
+// Consensus returns the underlying consensus object
+func (t *Typed[T]) Consensus() *Consensus { return t.consensus }
+
+// Decode decodes a state to a value, results are cached by StateHash
+func (t *Typed[T]) Decode(s State) (T, error) {
+	hash := t.consensus.StateHash(s)
+	t.cacheMu.Lock()
+	entry, ok := t.cache[hash]
+	t.cacheMu.Unlock()
+	if ok {
+		return entry.v, entry.err
+	}
+
+	entry.v, entry.err = t.typed.Codec.Decode(s)
+	t.store(hash, entry)
+	return entry.v, entry.err
+}
+
+// store caches a decoding result, an arbitrary entry will be evicted if the
+// cache is full.
+func (t *Typed[T]) store(hash StateHash, entry typedEntry[T]) {
+	t.cacheMu.Lock()
+	defer t.cacheMu.Unlock()
+	if _, ok := t.cache[hash]; !ok && len(t.cache) >= MaxTypedCacheSize {
+		for k := range t.cache {
+			delete(t.cache, k)
+			break
+		}
+	}
+	t.cache[hash] = entry
+}
+
+// Propose encodes and proposes a value, awaiting to be finalized at next height.
+func (t *Typed[T]) Propose(v T) error {
+	s, err := t.typed.Codec.Encode(v)
+	if err != nil {
+		return err
+	}
+
+	t.store(t.consensus.StateHash(s), typedEntry[T]{v: v})
+
+	t.consensus.Propose(s)
+	return nil
+}
+
+// ReceiveMessage processes incoming consensus messages, as Consensus.ReceiveMessage
+func (t *Typed[T]) ReceiveMessage(bts []byte, now time.Time) error {
+	return t.consensus.ReceiveMessage(bts, now)
+}
+
+// Update processes timing events, as Consensus.Update
+func (t *Typed[T]) Update(now time.Time) error { return t.consensus.Update(now) }
+
+// CurrentState returns the decided value at current height, ok is false if
+// nothing has been decided yet, or the decided state cannot be decoded.
+func (t *Typed[T]) CurrentState() (height uint64, round uint64, v T, ok bool) {
+	height, round, s := t.consensus.CurrentState()
+	if s == nil {
+		return height, round, v, false
+	}
+
+	v, err := t.Decode(s)
+	return height, round, v, err == nil
+}
+
+// compare is the StateCompare function for consensus
+func (t *Typed[T]) compare(a State, b State) int {
+	va, errA := t.Decode(a)
+	vb, errB := t.Decode(b)
+	switch {
+	case errA != nil && errB != nil:
+		return bytes.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return t.typed.Compare(va, vb)
+}
+
+// validate is the StateValidate function for consensus
+func (t *Typed[T]) validate(s State) bool {
+	v, err := t.Decode(s)
+	if err != nil {
+		return false
+	}
+	return t.typed.Validate(v)
+}
+
+// decided delivers the decided value, and clears the cache of previous height
+func (t *Typed[T]) decided(height uint64, round uint64, s State, proof *SignedProto) {
+	v, err := t.Decode(s)
+
+	t.cacheMu.Lock()
+	t.cache = make(map[StateHash]typedEntry[T])
+	t.cacheMu.Unlock()
+
+	if err == nil && t.typed.DecideCallback != nil {
+		t.typed.DecideCallback(height, round, v, proof)
+	}
+}
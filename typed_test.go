@@ -0,0 +1,128 @@
+package bdls
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testValue struct {
+	Height uint64
+	Data   string
+}
+
+// testCodec encodes testValue as |Height(8bytes)|Data|, and counts decodings
+type testCodec struct {
+	decodes int
+}
+
+func (c *testCodec) Encode(v testValue) (State, error) {
+	s := make(State, 8+len(v.Data))
+	binary.LittleEndian.PutUint64(s, v.Height)
+	copy(s[8:], v.Data)
+	return s, nil
+}
+
+func (c *testCodec) Decode(s State) (v testValue, err error) {
+	c.decodes++
+	if len(s) < 8 {
+		return v, errors.New("malformed")
+	}
+	v.Height = binary.LittleEndian.Uint64(s)
+	v.Data = string(s[8:])
+	return v, nil
+}
+
+func TestTyped(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(S256Curve, rand.Reader)
+	assert.Nil(t, err)
+	config := new(Config)
+	config.Epoch = time.Now()
+	config.PrivateKey = privateKey
+	for i := 0; i < 4; i++ {
+		config.Participants = append(config.Participants, DefaultPubKeyToIdentity(&privateKey.PublicKey))
+	}
+
+	codec := new(testCodec)
+	var decided []testValue
+	typed, err := NewTyped(config, &TypedConfig[testValue]{
+		Codec:    codec,
+		Compare:  func(a testValue, b testValue) int { return strings.Compare(a.Data, b.Data) },
+		Validate: func(v testValue) bool { return v.Height == 1 },
+		DecideCallback: func(height uint64, round uint64, v testValue, proof *SignedProto) {
+			decided = append(decided, v)
+		},
+	})
+	assert.Nil(t, err)
+
+	a, _ := codec.Encode(testValue{1, "a"})
+	b, _ := codec.Encode(testValue{1, "b"})
+	invalid, _ := codec.Encode(testValue{2, "c"})
+	malformed := State("bad")
+
+	// states are decoded once
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, -1, typed.compare(a, b))
+		assert.Equal(t, 1, typed.compare(b, a))
+		assert.Equal(t, 1, typed.compare(a, malformed))
+		assert.True(t, typed.validate(a))
+		assert.False(t, typed.validate(invalid))
+		assert.False(t, typed.validate(malformed))
+	}
+	assert.Equal(t, 4, codec.decodes)
+
+	// the caller's config is left untouched
+	assert.Nil(t, config.StateCompare)
+	assert.Nil(t, config.StateValidate)
+	assert.Nil(t, config.DecideCallback)
+
+	// proposed values need not to be decoded
+	assert.Nil(t, typed.Propose(testValue{1, "d"}))
+	d, _ := codec.Encode(testValue{1, "d"})
+	assert.True(t, typed.Consensus().HasProposed(d))
+	assert.True(t, typed.validate(d))
+	assert.Equal(t, 4, codec.decodes)
+
+	_, _, _, ok := typed.CurrentState()
+	assert.False(t, ok)
+
+	// the decided value will be delivered, and the cache will be cleared
+	typed.Consensus().heightSync(1, 0, b, time.Now())
+	assert.Equal(t, []testValue{{1, "b"}}, decided)
+	height, _, v, ok := typed.CurrentState()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), height)
+	assert.Equal(t, testValue{1, "b"}, v)
+
+	decodes := codec.decodes
+	assert.True(t, typed.validate(a))
+	assert.Equal(t, decodes+1, codec.decodes)
+
+	// the cache is bounded within a height
+	for i := 0; i < 2*MaxTypedCacheSize; i++ {
+		s, _ := codec.Encode(testValue{1, fmt.Sprint(i)})
+		assert.True(t, typed.validate(s))
+	}
+	assert.Equal(t, MaxTypedCacheSize, len(typed.cache))
+
+	// a config can be reused without wrapping callbacks twice
+	var calls int
+	config.DecideCallback = func(height uint64, round uint64, s State, proof *SignedProto) { calls++ }
+	for i := 0; i < 2; i++ {
+		another, err := NewTyped(config, &TypedConfig[testValue]{
+			Codec:    codec,
+			Compare:  func(a testValue, b testValue) int { return strings.Compare(a.Data, b.Data) },
+			Validate: func(v testValue) bool { return true },
+		})
+		assert.Nil(t, err)
+		another.Consensus().heightSync(1, 0, b, time.Now())
+	}
+	assert.Equal(t, 2, calls)
+}
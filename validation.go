@@ -0,0 +1,276 @@
+package bdls
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	proto "github.com/gogo/protobuf/proto"
+)
+
+// validationJob is a state queued for asynchronous validation
+type validationJob struct {
+	height uint64    // the height at which the validation was started
+	hash   StateHash // hash of the state
+	state  State
+}
+
+// verdict is the result of an asynchronous state validation
+type verdict struct {
+	height uint64    // the height at which the validation was started
+	hash   StateHash // hash of the validated state
+	valid  bool      // result of StateValidate
+}
+
+// asyncValidator runs StateValidate off the state machine on a bounded number
+// of workers, and memoizes the verdicts by StateHash within a height. Fields
+// guarded by mu are shared with workers, others are accessed from the state
+// machine only.
+type asyncValidator struct {
+	validate   func(State) bool
+	maxWorkers int // maximum number of concurrent StateValidate calls
+
+	verdicts   map[StateHash]bool // known verdicts at current height
+	validating map[StateHash]bool // states being validated at current height
+	parked     [][]byte           // messages awaiting verdicts
+
+	height  uint64          // current height, queued jobs of other heights are skipped
+	queue   []validationJob // states awaiting a worker
+	workers int             // number of running workers
+	results []verdict       // verdicts delivered from workers
+	mu      sync.Mutex
+
+	// notification for new verdicts
+	notify chan struct{}
+}
+
+// newAsyncValidator creates an async validator with StateValidate function
+// at the current height, at most runtime.NumCPU() states are validated
+// concurrently.
+func newAsyncValidator(validate func(State) bool, height uint64) *asyncValidator {
+	v := new(asyncValidator)
+	v.validate = validate
+	v.height = height
+	v.maxWorkers = runtime.NumCPU()
+	v.verdicts = make(map[StateHash]bool)
+	v.validating = make(map[StateHash]bool)
+	v.notify = make(chan struct{}, 1)
+	return v
+}
+
+// maxParkedMessages returns the maximum number of parked messages, a
+// participant sends a few messages for each round.
+func (c *Consensus) maxParkedMessages() int { return 4 * c.numIdentities }
+
+// maxValidatingStates returns the maximum number of states being validated
+// at a height, messages with more states will be rejected until some verdicts
+// are known.
+func (c *Consensus) maxValidatingStates() int { return 2 * c.numIdentities }
+
+// start queues a state for validation, and spawns a worker if there are
+// less than maxWorkers.
+func (v *asyncValidator) start(job validationJob) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.queue = append(v.queue, job)
+	if v.workers < v.maxWorkers {
+		v.workers++
+		go v.work()
+	}
+}
+
+// work validates queued states until the queue is empty, jobs started at
+// previous heights are skipped.
+func (v *asyncValidator) work() {
+	for {
+		v.mu.Lock()
+		if len(v.queue) == 0 {
+			v.workers--
+			v.mu.Unlock()
+			return
+		}
+		job := v.queue[0]
+		v.queue[0] = validationJob{}
+		v.queue = v.queue[1:]
+		stale := job.height != v.height
+		v.mu.Unlock()
+
+		if stale {
+			continue
+		}
+
+		valid := v.validate(job.state)
+		v.mu.Lock()
+		v.results = append(v.results, verdict{height: job.height, hash: job.hash, valid: valid})
+		v.mu.Unlock()
+
+		select {
+		case v.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// validateState validates a state, returns nil if valid, the invalid error
+// if invalid, or ErrStateValidationPending if the verdict is not yet known
+// in async validation mode.
+func (c *Consensus) validateState(s State, invalid error) error {
+	v := c.asyncValidator
+	if v == nil {
+		if !c.stateValidate(s) {
+			return invalid
+		}
+		return nil
+	}
+
+	hash := c.stateHash(s)
+	if valid, ok := v.verdicts[hash]; ok {
+		if !valid {
+			return invalid
+		}
+		return nil
+	}
+
+	// start validation off the state machine
+	if !v.validating[hash] {
+		if len(v.validating) >= c.maxValidatingStates() {
+			return ErrStateValidationLimit
+		}
+		v.validating[hash] = true
+		v.start(validationJob{height: c.latestHeight, hash: hash, state: s})
+	}
+	return ErrStateValidationPending
+}
+
+// validateStateSync validates a state on the caller's goroutine even in async
+// validation mode, for light clients which cannot wait for verdicts, known
+// verdicts are reused.
+func (c *Consensus) validateStateSync(s State, invalid error) error {
+	if v := c.asyncValidator; v != nil {
+		if valid, ok := v.verdicts[c.stateHash(s)]; ok {
+			if !valid {
+				return invalid
+			}
+			return nil
+		}
+	}
+
+	if !c.stateValidate(s) {
+		return invalid
+	}
+	return nil
+}
+
+// prevalidate starts validation for all states carried by a message and its
+// proofs in async validation mode, so the verdicts are awaited at once,
+// rather than one state per round of parking.
+func (c *Consensus) prevalidate(m *Message) {
+	if c.asyncValidator == nil || m.Height <= c.latestHeight {
+		return
+	}
+
+	if m.State != nil {
+		_ = c.validateState(m.State, nil)
+	}
+
+	for _, proof := range m.Proof {
+		if proof == nil {
+			continue
+		}
+		mProof := new(Message)
+		if err := proto.Unmarshal(proof.Message, mProof); err != nil {
+			continue
+		}
+		if mProof.State != nil {
+			_ = c.validateState(mProof.State, nil)
+		}
+	}
+}
+
+// parkMessage parks a message awaiting verdicts, the oldest message will
+// be dropped if there are too many.
+func (c *Consensus) parkMessage(bts []byte) {
+	v := c.asyncValidator
+	if len(v.parked) >= c.maxParkedMessages() {
+		v.parked[0] = nil
+		v.parked = v.parked[1:]
+	}
+	v.parked = append(v.parked, bts)
+}
+
+// processVerdicts collects verdicts from validation goroutines, and
+// re-processes parked messages if any verdict is new.
+func (c *Consensus) processVerdicts(now time.Time) {
+	v := c.asyncValidator
+	if v == nil {
+		return
+	}
+
+	v.mu.Lock()
+	results := v.results
+	v.results = nil
+	v.mu.Unlock()
+
+	var updated bool
+	for _, r := range results {
+		// verdicts for states from previous heights are discarded
+		if r.height == c.latestHeight && v.validating[r.hash] {
+			delete(v.validating, r.hash)
+			v.verdicts[r.hash] = r.valid
+			updated = true
+		}
+	}
+
+	if !updated {
+		return
+	}
+
+	parked := v.parked
+	v.parked = nil
+	height := c.latestHeight
+	for _, bts := range parked {
+		// parked messages have been cleared on new height
+		if c.latestHeight != height {
+			return
+		}
+		_ = c.receiveMessage(bts, now)
+	}
+}
+
+// resetValidation clears verdicts and parked messages on new height
+func (c *Consensus) resetValidation() {
+	v := c.asyncValidator
+	if v == nil {
+		return
+	}
+	v.verdicts = make(map[StateHash]bool)
+	v.validating = make(map[StateHash]bool)
+	v.parked = nil
+
+	// queued jobs and undelivered verdicts are stale
+	v.mu.Lock()
+	v.height = c.latestHeight
+	v.queue = nil
+	v.results = nil
+	v.mu.Unlock()
+}
+
+// ValidationNotify returns a channel which will be signalled when new
+// verdicts are ready in async validation mode, Update should be called
+// then to process the messages parked for them. The channel will never be
+// signalled if async validation is disabled.
+func (c *Consensus) ValidationNotify() <-chan struct{} {
+	if c.asyncValidator == nil {
+		return nil
+	}
+	return c.asyncValidator.notify
+}
+
+// NumParkedMessages returns count of messages awaiting verdicts in async
+// validation mode.
+func (c *Consensus) NumParkedMessages() int {
+	if c.asyncValidator == nil {
+		return 0
+	}
+	return len(c.asyncValidator.parked)
+}